@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// requestTimeout is the time given to every unary call
+const requestTimeout = 10 * time.Second
+
+// connection holds the grpc clients of a server and the session token.
+type connection struct {
+	conn *grpc.ClientConn
+
+	auth          pb.AuthServiceClient
+	chat          pb.ChatServiceClient
+	commands      pb.CommandServiceClient
+	announcements pb.AnnouncementServiceClient
+	presence      pb.PresenceServiceClient
+
+	mu    sync.Mutex
+	token string // changes when the session is refreshed
+
+	certAuth bool // a client certificate authenticates the requests instead of a token
+
+	// streams live until the connection is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type (
+	loginMsg struct {
+		status  pb.LoginResponse_Status
+		expires time.Time
+	}
+	refreshMsg struct {
+		expires time.Time
+	}
+	registerMsg struct {
+		status pb.RegisterResponse_Status
+	}
+	messageStreamMsg struct {
+		stream pb.ChatService_SubscribeMessageClient
+	}
+	announcementStreamMsg struct {
+		stream pb.AnnouncementService_SendAnnouncementClient
+	}
+	presenceStreamMsg struct {
+		stream pb.PresenceService_SubscribePresenceClient
+	}
+	roomMsg struct {
+		action string // create, join or leave
+		room   string
+		status pb.RoomResponse_Status
+	}
+	roomListMsg     []*pb.RoomInfo
+	sessionListMsg  []*pb.Session
+	refreshDueMsg   struct{}
+	commandMsg      *pb.CommandR
+	chatMsg         *pb.SubMessage
+	announcementMsg *pb.SubAnnouncement
+	presenceMsg     *pb.PresenceEvent
+	streamClosedMsg struct {
+		name string
+		err  error
+	}
+)
+
+// dial connects to the server trusting only the given ca certificate. When
+// certPath and keyPath are set the client certificate is presented to the
+// server, which logs in its user without a password.
+func dial(address string, caPath string, serverName string, certPath string, keyPath string) (*connection, error) {
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		serverName = host
+	}
+
+	creds, err := clientCredentials(caPath, serverName, certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &connection{
+		conn:          conn,
+		auth:          pb.NewAuthServiceClient(conn),
+		chat:          pb.NewChatServiceClient(conn),
+		commands:      pb.NewCommandServiceClient(conn),
+		announcements: pb.NewAnnouncementServiceClient(conn),
+		presence:      pb.NewPresenceServiceClient(conn),
+		certAuth:      certPath != "",
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+// clientCredentials trusts only the root of the ca chain at caPath, the
+// server sends the intermediates its certificate needs.
+func clientCredentials(caPath string, serverName string, certPath string, keyPath string) (credentials.TransportCredentials, error) {
+	data, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := utils.ParseCAChain(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ca chain in %v: %v", caPath, err)
+	}
+
+	config := &tls.Config{
+		RootCAs:    chain.RootPool(),
+		ServerName: serverName,
+	}
+	if certPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(config), nil
+}
+
+func (c *connection) getToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token
+}
+
+func (c *connection) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = token
+}
+
+// authContext adds the session token to the outgoing metadata.
+func (c *connection) authContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.getToken())
+}
+
+// clientInfo describes this device in the session list of the user.
+func clientInfo() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("%s (%s/%s)", host, runtime.GOOS, runtime.GOARCH)
+}
+
+// millis converts unix milliseconds sent by the server to a time.
+func millis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func (c *connection) login(username string, password string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.ctx, requestTimeout)
+		defer cancel()
+
+		resp, err := c.auth.Login(ctx, &pb.LoginRequest{Username: username, Password: password, Client: clientInfo()})
+		if err != nil {
+			return errMsg(err)
+		}
+		if resp.Status == pb.LoginResponse_SUCCESS {
+			c.setToken(resp.Token)
+		}
+
+		return loginMsg{status: resp.Status, expires: millis(resp.Expires)}
+	}
+}
+
+// refreshAfter waits until half of the time left before the session expires
+// and asks for a refresh.
+func refreshAfter(expires time.Time) tea.Cmd {
+	return tea.Tick(time.Until(expires)/2, func(time.Time) tea.Msg {
+		return refreshDueMsg{}
+	})
+}
+
+// refresh renews the session, the new token is used by the next requests.
+func (c *connection) refresh() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := c.auth.Refresh(ctx, &pb.RefreshRequest{})
+		if err != nil {
+			return errMsg(err)
+		}
+		c.setToken(resp.Token)
+
+		return refreshMsg{expires: millis(resp.Expires)}
+	}
+}
+
+func (c *connection) listSessions() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := c.auth.ListSessions(ctx, &pb.ListSessionsRequest{})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		return sessionListMsg(resp.Sessions)
+	}
+}
+
+// revokeSession logs out the session whose id starts with prefix, as shown
+// by /sessions.
+func (c *connection) revokeSession(prefix string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		list, err := c.auth.ListSessions(ctx, &pb.ListSessionsRequest{})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		var matches []*pb.Session
+		for _, session := range list.Sessions {
+			if strings.HasPrefix(session.Id, prefix) {
+				matches = append(matches, session)
+			}
+		}
+		if len(matches) == 0 {
+			return errMsg(status.Error(codes.NotFound, "no session "+prefix))
+		}
+		if len(matches) > 1 {
+			return errMsg(status.Error(codes.InvalidArgument, "more than one session starts with "+prefix))
+		}
+
+		session := matches[0]
+		resp, err := c.auth.RevokeSession(ctx, &pb.RevokeSessionRequest{SessionId: session.Id})
+		if err != nil {
+			return errMsg(err)
+		}
+		if resp.Status != pb.RevokeSessionResponse_SUCCESS {
+			return errMsg(status.Error(codes.NotFound, "no session "+prefix))
+		}
+
+		if session.Current {
+			c.setToken("")
+			return commandMsg(&pb.CommandR{Message: "Revoked this session, restart the client to log in again"})
+		}
+		return commandMsg(&pb.CommandR{Message: "Revoked session " + shortId(session.Id) + " on " + session.Client})
+	}
+}
+
+// shortId is the part of a session id shown to the user, enough to revoke it.
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func (c *connection) register(username string, password string, serverPassword string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.ctx, requestTimeout)
+		defer cancel()
+
+		resp, err := c.auth.Register(ctx, &pb.RegisterRequest{
+			Username:       username,
+			Password:       password,
+			ServerPassword: serverPassword,
+		})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		return registerMsg{status: resp.Status}
+	}
+}
+
+func (c *connection) sendMessage(room string, message string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := c.chat.SendMessage(ctx, &pb.MessageS{Message: message, Room: room})
+		if err != nil {
+			return errMsg(err)
+		}
+		switch resp.Status {
+		case pb.MessageR_OK:
+			return nil
+		case pb.MessageR_ROOM_NOT_FOUND:
+			return errMsg(status.Error(codes.NotFound, "room "+room+" does not exist"))
+		case pb.MessageR_NOT_A_MEMBER:
+			return errMsg(status.Error(codes.PermissionDenied, "you are not in room "+room+", /join it first"))
+		}
+		return errMsg(status.Error(codes.InvalidArgument, "message was not accepted"))
+	}
+}
+
+func (c *connection) sendDirectMessage(recipient string, message string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := c.chat.SendDirectMessage(ctx, &pb.DirectMessageS{Recipient: recipient, Message: message})
+		if err != nil {
+			return errMsg(err)
+		}
+		switch resp.Status {
+		case pb.DirectMessageR_OK:
+			return nil
+		case pb.DirectMessageR_QUEUED:
+			return commandMsg(&pb.CommandR{Message: recipient + " is not connected, the message is delivered when they connect"})
+		case pb.DirectMessageR_UNKNOWN_USER:
+			return errMsg(status.Error(codes.NotFound, "user "+recipient+" does not exist"))
+		case pb.DirectMessageR_QUEUE_FULL:
+			return errMsg(status.Error(codes.ResourceExhausted, recipient+" has too many messages waiting"))
+		}
+		return errMsg(status.Error(codes.InvalidArgument, "message was not accepted"))
+	}
+}
+
+// sendCommand runs a slash command, line is the command as typed by the user
+// in the given room.
+func (c *connection) sendCommand(room string, line string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		fields := strings.Fields(line)
+		resp, err := c.commands.SendCommand(ctx, &pb.CommandS{Command: fields[0], Args: fields[1:], Room: room})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		return commandMsg(resp)
+	}
+}
+
+// roomRequest runs one of the room rpcs, action names it in the result.
+func (c *connection) roomRequest(action string, room string, topic string) tea.Cmd {
+	call := map[string]func(context.Context, *pb.RoomRequest, ...grpc.CallOption) (*pb.RoomResponse, error){
+		"create": c.chat.CreateRoom,
+		"join":   c.chat.JoinRoom,
+		"leave":  c.chat.LeaveRoom,
+	}[action]
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := call(ctx, &pb.RoomRequest{Room: room, Topic: topic})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		return roomMsg{action: action, room: room, status: resp.Status}
+	}
+}
+
+func (c *connection) listRooms() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(c.authContext(c.ctx), requestTimeout)
+		defer cancel()
+
+		resp, err := c.chat.ListRooms(ctx, &pb.ListRoomsRequest{})
+		if err != nil {
+			return errMsg(err)
+		}
+
+		return roomListMsg(resp.Rooms)
+	}
+}
+
+// subscribeMessages opens the message stream replaying the last backlog messages.
+func (c *connection) subscribeMessages(backlog uint32) tea.Cmd {
+	return func() tea.Msg {
+		stream, err := c.chat.SubscribeMessage(c.authContext(c.ctx), &pb.SubRequest{Backlog: backlog})
+		if err != nil {
+			return streamClosedMsg{name: "messages", err: err}
+		}
+
+		return messageStreamMsg{stream: stream}
+	}
+}
+
+func (c *connection) subscribeAnnouncements() tea.Cmd {
+	return func() tea.Msg {
+		stream, err := c.announcements.SendAnnouncement(c.authContext(c.ctx), &pb.SubRequest{})
+		if err != nil {
+			return streamClosedMsg{name: "announcements", err: err}
+		}
+
+		return announcementStreamMsg{stream: stream}
+	}
+}
+
+func (c *connection) subscribePresence() tea.Cmd {
+	return func() tea.Msg {
+		stream, err := c.presence.SubscribePresence(c.authContext(c.ctx), &pb.SubRequest{})
+		if err != nil {
+			return streamClosedMsg{name: "presence", err: err}
+		}
+
+		return presenceStreamMsg{stream: stream}
+	}
+}
+
+// waitForMessage turns the next item of the message stream into a tea message.
+func waitForMessage(stream pb.ChatService_SubscribeMessageClient) tea.Cmd {
+	return func() tea.Msg {
+		msg, err := stream.Recv()
+		if err != nil {
+			return streamClosedMsg{name: "messages", err: err}
+		}
+
+		return chatMsg(msg)
+	}
+}
+
+// waitForAnnouncement turns the next item of the announcement stream into a tea message.
+func waitForAnnouncement(stream pb.AnnouncementService_SendAnnouncementClient) tea.Cmd {
+	return func() tea.Msg {
+		msg, err := stream.Recv()
+		if err != nil {
+			return streamClosedMsg{name: "announcements", err: err}
+		}
+
+		return announcementMsg(msg)
+	}
+}
+
+// waitForPresence turns the next item of the presence stream into a tea message.
+func waitForPresence(stream pb.PresenceService_SubscribePresenceClient) tea.Cmd {
+	return func() tea.Msg {
+		msg, err := stream.Recv()
+		if err != nil {
+			return streamClosedMsg{name: "presence", err: err}
+		}
+
+		return presenceMsg(msg)
+	}
+}
+
+// close logs out if there is a session and closes the connection.
+func (c *connection) close() {
+	if c.getToken() != "" || c.certAuth {
+		ctx, cancel := context.WithTimeout(c.authContext(context.Background()), requestTimeout)
+		c.auth.Logout(ctx, &pb.LogoutRequest{})
+		cancel()
+	}
+
+	c.cancel()
+	c.conn.Close()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+const (
+	helloVersion     = 2               // version of the hello protocol spoken by the client
+	helloTimeout     = 2 * time.Second // wait for the fragments before asking again
+	helloAttempts    = 3
+	maxHelloFragment = 64   // fragments of the largest chain accepted
+	maxHelloDatagram = 2048 // larger than any fragment the server sends
+	minHelloRequest  = 256  // the server drops smaller requests
+)
+
+// helloInfo is what the hello server tells about itself.
+type helloInfo struct {
+	chain      *utils.CAChain // root and intermediate cas, checked against each other
+	serverName string         // name in the server certificate
+	port       uint32         // of the grpc server
+}
+
+// sayHello asks the hello server at address for its ca chain and grpc
+// address. Fragments that get lost are asked again.
+func sayHello(address string) (*helloInfo, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var (
+		answer   = newHelloAssembly()
+		cookie   []byte
+		answered bool // the cookie challenge of the server
+		buffer   = make([]byte, maxHelloDatagram)
+	)
+	for attempt := 0; attempt < helloAttempts; attempt++ {
+		req := &pb.HelloRequest{Version: helloVersion, Nonce: nonce, Cookie: cookie, Fragments: answer.missing()}
+		data, err := marshalHello(req)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(data); err != nil {
+			return nil, err
+		}
+
+		conn.SetReadDeadline(time.Now().Add(helloTimeout))
+		challenged := false
+		for !answer.complete() {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				break // timed out, ask for the missing fragments
+			}
+
+			fragment := &pb.HelloFragment{}
+			if proto.Unmarshal(buffer[:n], fragment) != nil || !bytes.Equal(fragment.Nonce, nonce) {
+				continue // not an answer to this request
+			}
+			if fragment.Status == pb.HelloFragment_UNSUPPORTED_VERSION {
+				return nil, fmt.Errorf("the server speaks hello version %d, this client %d", fragment.Version, helloVersion)
+			}
+			if fragment.Status == pb.HelloFragment_COOKIE_REQUIRED {
+				// the server sends the chain once it knows the client receives at its address
+				cookie = fragment.Cookie
+				challenged = true
+				break
+			}
+			if err := answer.add(fragment); err != nil {
+				return nil, err
+			}
+		}
+
+		if answer.complete() {
+			return answer.assemble()
+		}
+		if challenged && !answered {
+			attempt-- // answering the first challenge is not a lost attempt
+			answered = true
+		}
+	}
+
+	if answer.first == nil {
+		return nil, errors.New("the server did not answer hello")
+	}
+	return nil, fmt.Errorf("received %d of %d hello fragments", len(answer.fragments), answer.first.Count)
+}
+
+// marshalHello encodes a request padded to the size the server answers.
+func marshalHello(req *pb.HelloRequest) ([]byte, error) {
+	for size := proto.Size(req); size < minHelloRequest; size = proto.Size(req) {
+		req.Padding = append(req.Padding, make([]byte, minHelloRequest-size)...)
+	}
+	return proto.Marshal(req)
+}
+
+// checkFragment verifies a fragment and that it belongs to the same answer
+// as the first one received.
+func checkFragment(fragment *pb.HelloFragment, first *pb.HelloFragment) error {
+	if fragment.Count == 0 || fragment.Count > maxHelloFragment || fragment.Index >= fragment.Count {
+		return fmt.Errorf("invalid hello fragment %d of %d", fragment.Index, fragment.Count)
+	}
+	if crc32.ChecksumIEEE(fragment.Data) != fragment.Checksum {
+		return fmt.Errorf("hello fragment %d is corrupted", fragment.Index)
+	}
+	if first != nil && (fragment.Count != first.Count || !bytes.Equal(fragment.ChainSha256, first.ChainSha256) ||
+		fragment.ServerName != first.ServerName || fragment.Port != first.Port) {
+		return errors.New("hello fragments of different answers")
+	}
+	return nil
+}
+
+// helloAssembly collects the fragments of one hello answer, in any order.
+type helloAssembly struct {
+	first     *pb.HelloFragment
+	fragments map[uint32][]byte // index: data
+}
+
+func newHelloAssembly() *helloAssembly {
+	return &helloAssembly{fragments: make(map[uint32][]byte)}
+}
+
+// add checks a fragment and keeps it, a repeated fragment replaces the
+// previous copy.
+func (a *helloAssembly) add(fragment *pb.HelloFragment) error {
+	if err := checkFragment(fragment, a.first); err != nil {
+		return err
+	}
+
+	if a.first == nil {
+		a.first = fragment
+	}
+	a.fragments[fragment.Index] = fragment.Data
+	return nil
+}
+
+// missing returns the indexes of the fragments not received yet, none before
+// the first one tells how many there are.
+func (a *helloAssembly) missing() []uint32 {
+	if a.first == nil {
+		return nil
+	}
+
+	var missing []uint32
+	for i := uint32(0); i < a.first.Count; i++ {
+		if _, ok := a.fragments[i]; !ok {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+func (a *helloAssembly) complete() bool {
+	return a.first != nil && len(a.fragments) == int(a.first.Count)
+}
+
+// assemble joins the fragments in order and checks the result against its
+// hash. It must be a ca chain whose intermediates are all signed by its root,
+// the root is what the user pins.
+func (a *helloAssembly) assemble() (*helloInfo, error) {
+	if !a.complete() {
+		return nil, errors.New("hello fragments are missing")
+	}
+
+	var data []byte
+	for i := uint32(0); i < a.first.Count; i++ {
+		data = append(data, a.fragments[i]...)
+	}
+
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], a.first.ChainSha256) {
+		return nil, errors.New("the reassembled ca chain does not match its checksum")
+	}
+	chain, err := utils.ParseCAChain(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ca chain: %v", err)
+	}
+
+	return &helloInfo{chain: chain, serverName: a.first.ServerName, port: a.first.Port}, nil
+}
+
+// grpcAddress returns the address of the grpc server, on the host of the
+// hello server and the port it announced.
+func (h *helloInfo) grpcAddress(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || h.port == 0 {
+		return address
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(h.port)))
+}
+
+// discoveryGroup is the multicast group servers join to be discovered
+var discoveryGroup = net.IPv4(239, 255, 84, 21)
+
+// discoveredServer is a server that answered a discovery request.
+type discoveredServer struct {
+	address          string // of its hello server, to connect to
+	name             string
+	clients          uint32
+	maxClients       uint32
+	passwordRequired bool
+}
+
+// discover broadcasts a discovery request, and sends it to the multicast
+// group, on the hello port and returns the servers that answered within wait.
+func discover(port int, wait time.Duration) ([]discoveredServer, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	data, err := marshalHello(&pb.HelloRequest{Version: helloVersion, Nonce: nonce, Discover: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sent := 0
+	for _, ip := range []net.IP{net.IPv4bcast, discoveryGroup} {
+		if _, err := conn.WriteToUDP(data, &net.UDPAddr{IP: ip, Port: port}); err == nil {
+			sent++
+		}
+	}
+	if sent == 0 {
+		return nil, errors.New("could not send a discovery request")
+	}
+
+	// a server can answer both requests, it is listed once
+	seen := make(map[string]bool)
+	var servers []discoveredServer
+	buffer := make([]byte, maxHelloDatagram)
+	conn.SetReadDeadline(time.Now().Add(wait))
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			return servers, nil // wait is over
+		}
+
+		answer := &pb.HelloFragment{}
+		if proto.Unmarshal(buffer[:n], answer) != nil || !bytes.Equal(answer.Nonce, nonce) ||
+			answer.Status != pb.HelloFragment_OK || seen[from.String()] {
+			continue
+		}
+		seen[from.String()] = true
+
+		servers = append(servers, discoveredServer{
+			address:          from.String(),
+			name:             answer.Name,
+			clients:          answer.Clients,
+			maxClients:       answer.MaxClients,
+			passwordRequired: answer.PasswordRequired,
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"hash/crc32"
+	"math/big"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// newTestCA returns a ca certificate signed by parent and its key, or a self
+// signed one when parent is nil.
+func newTestCA(t *testing.T, name string, parent *x509.Certificate, parentKey ed25519.PrivateKey) (*x509.Certificate, ed25519.PrivateKey) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func encodeCerts(certs ...*x509.Certificate) []byte {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return data
+}
+
+// testCA returns a PEM self signed ca certificate.
+func testCA(t *testing.T, name string) []byte {
+	t.Helper()
+
+	cert, _ := newTestCA(t, name, nil, nil)
+	return encodeCerts(cert)
+}
+
+// split cuts data in fragments of size bytes like the hello server.
+func split(data []byte, size int) []*pb.HelloFragment {
+	count := (len(data) + size - 1) / size
+	sum := sha256.Sum256(data)
+
+	var fragments []*pb.HelloFragment
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		part := data[i*size : end]
+		fragments = append(fragments, &pb.HelloFragment{
+			ServerName:  "chat.example.org",
+			Port:        8421,
+			Index:       uint32(i),
+			Count:       uint32(count),
+			Data:        part,
+			Checksum:    crc32.ChecksumIEEE(part),
+			ChainSha256: sum[:],
+		})
+	}
+	return fragments
+}
+
+func assembleAll(t *testing.T, fragments []*pb.HelloFragment) (*helloInfo, error) {
+	t.Helper()
+
+	answer := newHelloAssembly()
+	for _, fragment := range fragments {
+		if err := answer.add(fragment); err != nil {
+			t.Fatalf("fragment %d: %v", fragment.Index, err)
+		}
+	}
+	return answer.assemble()
+}
+
+func TestAssembleInOrder(t *testing.T) {
+	ca := testCA(t, "ca")
+	fragments := split(ca, 100)
+	if len(fragments) < 3 {
+		t.Fatalf("want several fragments, got %d", len(fragments))
+	}
+
+	hello, err := assembleAll(t, fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hello.chain.PEM(), ca) || hello.serverName != "chat.example.org" || hello.port != 8421 {
+		t.Fatalf("got %+v", hello)
+	}
+}
+
+func TestAssembleOutOfOrder(t *testing.T) {
+	ca := testCA(t, "ca")
+	fragments := split(ca, 100)
+
+	reversed := make([]*pb.HelloFragment, 0, len(fragments))
+	for i := len(fragments) - 1; i >= 0; i-- {
+		reversed = append(reversed, fragments[i])
+	}
+
+	hello, err := assembleAll(t, reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hello.chain.PEM(), ca) {
+		t.Fatal("the reassembled ca differs")
+	}
+}
+
+func TestAssembleDuplicates(t *testing.T) {
+	ca := testCA(t, "ca")
+	fragments := split(ca, 100)
+
+	answer := newHelloAssembly()
+	for _, fragment := range []*pb.HelloFragment{fragments[0], fragments[0], fragments[1], fragments[0]} {
+		if err := answer.add(fragment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if answer.complete() {
+		t.Fatal("duplicates completed the answer")
+	}
+
+	for _, fragment := range fragments[1:] {
+		if err := answer.add(fragment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hello, err := answer.assemble()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hello.chain.PEM(), ca) {
+		t.Fatal("the reassembled ca differs")
+	}
+}
+
+func TestAssembleMissing(t *testing.T) {
+	fragments := split(testCA(t, "ca"), 100)
+
+	answer := newHelloAssembly()
+	if missing := answer.missing(); missing != nil {
+		t.Fatalf("got missing %v before any fragment", missing)
+	}
+	for i, fragment := range fragments {
+		if i == 1 || i == 3 {
+			continue
+		}
+		if err := answer.add(fragment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if answer.complete() {
+		t.Fatal("the answer is complete without fragments 1 and 3")
+	}
+	if missing := answer.missing(); len(missing) != 2 || missing[0] != 1 || missing[1] != 3 {
+		t.Fatalf("got missing %v, want [1 3]", missing)
+	}
+	if _, err := answer.assemble(); err == nil {
+		t.Fatal("assembled an incomplete answer")
+	}
+}
+
+func TestAssembleChecksumMismatch(t *testing.T) {
+	fragments := split(testCA(t, "ca"), 100)
+	fragments[1].Data = append([]byte("x"), fragments[1].Data[1:]...)
+
+	answer := newHelloAssembly()
+	answer.add(fragments[0])
+	if err := answer.add(fragments[1]); err == nil {
+		t.Fatal("accepted a fragment that does not match its crc")
+	}
+}
+
+func TestAssembleHashMismatch(t *testing.T) {
+	ca := testCA(t, "ca")
+	fragments := split(ca, 100)
+
+	// every fragment agrees on a hash that is not the one of the data
+	wrong := sha256.Sum256([]byte("something else"))
+	for _, fragment := range fragments {
+		fragment.ChainSha256 = wrong[:]
+	}
+
+	if _, err := assembleAll(t, fragments); err == nil {
+		t.Fatal("assembled a ca that does not match its hash")
+	}
+}
+
+func TestAssembleMixedAnswers(t *testing.T) {
+	first := split(testCA(t, "ca"), 100)
+	other := split(testCA(t, "other"), 100)
+
+	answer := newHelloAssembly()
+	if err := answer.add(first[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := answer.add(other[1]); err == nil {
+		t.Fatal("accepted a fragment of another answer")
+	}
+}
+
+func TestAssembleInvalidFragments(t *testing.T) {
+	fragments := split(testCA(t, "ca"), 100)
+
+	for name, change := range map[string]func(f *pb.HelloFragment){
+		"index past count": func(f *pb.HelloFragment) { f.Index = f.Count },
+		"no fragments":     func(f *pb.HelloFragment) { f.Count = 0 },
+		"too many":         func(f *pb.HelloFragment) { f.Count = maxHelloFragment + 1 },
+	} {
+		fragment := proto.Clone(fragments[0]).(*pb.HelloFragment)
+		change(fragment)
+		if err := newHelloAssembly().add(fragment); err == nil {
+			t.Errorf("%v: accepted the fragment", name)
+		}
+	}
+}
+
+func TestAssembleChain(t *testing.T) {
+	root, rootKey := newTestCA(t, "root", nil, nil)
+	intermediate, _ := newTestCA(t, "intermediate", root, rootKey)
+	chain := encodeCerts(intermediate, root)
+
+	hello, err := assembleAll(t, split(chain, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hello.chain.Root.Equal(root) || len(hello.chain.Certs) != 2 {
+		t.Fatalf("got root %v and %d certificates", hello.chain.Root.Subject, len(hello.chain.Certs))
+	}
+	if hello.chain.Fingerprint() != utils.Fingerprint(root.Raw) {
+		t.Fatal("the pinned fingerprint is not the one of the root")
+	}
+	if !bytes.Equal(hello.chain.PEM(), chain) {
+		t.Fatal("the reassembled chain differs")
+	}
+}
+
+func TestAssembleRefusesExtraCertificates(t *testing.T) {
+	// a well formed answer carrying a second root along with the real one
+	chain := append(testCA(t, "ca"), testCA(t, "attacker")...)
+
+	if _, err := assembleAll(t, split(chain, 100)); err == nil {
+		t.Fatal("assembled an answer with two roots")
+	}
+}
+
+func TestAssembleRefusesForeignIntermediate(t *testing.T) {
+	// an intermediate the root did not sign must not come in with it
+	root, _ := newTestCA(t, "root", nil, nil)
+	other, otherKey := newTestCA(t, "attacker", nil, nil)
+	intermediate, _ := newTestCA(t, "intermediate", other, otherKey)
+
+	if _, err := assembleAll(t, split(encodeCerts(intermediate, root), 100)); err == nil {
+		t.Fatal("assembled a chain with an intermediate of another root")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+)
+
+// login form fields
+const (
+	usernameField = iota
+	passwordField
+	serverPasswordField // only used to register
+)
+
+// loginForm asks for the credentials to log in or register.
+type loginForm struct {
+	inputs   []textinput.Model
+	focus    int
+	register bool
+	pending  bool // waiting for the server to answer
+	status   string
+}
+
+var (
+	titleStyle = lipgloss.NewStyle().Bold(true)
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+func newLoginForm() loginForm {
+	inputs := make([]textinput.Model, 3)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].CharLimit = 64
+	}
+
+	inputs[usernameField].Prompt = "Username: "
+	inputs[passwordField].Prompt = "Password: "
+	inputs[passwordField].EchoMode = textinput.EchoPassword
+	inputs[serverPasswordField].Prompt = "Server password: "
+	inputs[serverPasswordField].EchoMode = textinput.EchoPassword
+	inputs[serverPasswordField].Placeholder = "leave empty if the server has none"
+
+	inputs[usernameField].Focus()
+
+	return loginForm{inputs: inputs}
+}
+
+// fields returns the number of fields shown in the current mode.
+func (f loginForm) fields() int {
+	if f.register {
+		return 3
+	}
+	return 2
+}
+
+func (f *loginForm) setFocus(i int) {
+	f.inputs[f.focus].Blur()
+	f.focus = (i + f.fields()) % f.fields()
+	f.inputs[f.focus].Focus()
+}
+
+func (f loginForm) value(field int) string {
+	return strings.TrimSpace(f.inputs[field].Value())
+}
+
+// update handles the keys of the form and sends the login or register request
+// when the user presses enter on the last field.
+func (f loginForm) update(msg tea.Msg, c *connection) (loginForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && !f.pending {
+		switch msg.Type {
+		case tea.KeyTab, tea.KeyDown:
+			f.setFocus(f.focus + 1)
+			return f, nil
+		case tea.KeyShiftTab, tea.KeyUp:
+			f.setFocus(f.focus - 1)
+			return f, nil
+		case tea.KeyCtrlR:
+			f.register = !f.register
+			f.status = ""
+			f.setFocus(usernameField)
+			return f, nil
+		case tea.KeyEnter:
+			if f.focus < f.fields()-1 {
+				f.setFocus(f.focus + 1)
+				return f, nil
+			}
+			if f.value(usernameField) == "" || f.inputs[passwordField].Value() == "" {
+				f.status = "Username and password are required"
+				return f, nil
+			}
+
+			f.pending = true
+			f.status = ""
+			if f.register {
+				return f, c.register(f.value(usernameField), f.inputs[passwordField].Value(), f.inputs[serverPasswordField].Value())
+			}
+			return f, c.login(f.value(usernameField), f.inputs[passwordField].Value())
+		}
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return f, cmd
+}
+
+// loginStatus describes a failed login.
+func loginStatus(s pb.LoginResponse_Status) string {
+	switch s {
+	case pb.LoginResponse_INVALID_CREDENTIALS:
+		return "Invalid username or password"
+	case pb.LoginResponse_INVALID_SERVER_PASSWORD:
+		return "Invalid server password"
+	case pb.LoginResponse_USER_BANNED:
+		return "You are banned from this server"
+	case pb.LoginResponse_ALREADY_LOGGED_IN:
+		return "You are already logged in somewhere else"
+	case pb.LoginResponse_SERVER_FULL:
+		return "The server is full, try again later"
+	case pb.LoginResponse_TOO_MANY_CONNECTIONS:
+		return "Too many connections from your address"
+	case pb.LoginResponse_ACCOUNT_LOCKED:
+		return "Too many failed logins, the account is locked for a while"
+	}
+	return s.String()
+}
+
+// registerStatus describes a failed register.
+func registerStatus(s pb.RegisterResponse_Status) string {
+	switch s {
+	case pb.RegisterResponse_USERNAME_EXISTS:
+		return "Username already exists"
+	case pb.RegisterResponse_INVALID_SERVER_PASSWORD:
+		return "Invalid server password"
+	}
+	return s.String()
+}
+
+func (f loginForm) view(address string) string {
+	var b strings.Builder
+
+	if f.register {
+		b.WriteString(titleStyle.Render("Register on " + address))
+	} else {
+		b.WriteString(titleStyle.Render("Log in to " + address))
+	}
+	b.WriteString("\n\n")
+
+	for i := 0; i < f.fields(); i++ {
+		b.WriteString(f.inputs[i].View())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if f.pending {
+		b.WriteString("Waiting for the server...\n\n")
+	} else if f.status != "" {
+		b.WriteString(errorStyle.Render(f.status) + "\n\n")
+	}
+
+	if f.register {
+		b.WriteString(helpStyle.Render("tab: next field • ctrl+r: log in instead • enter: register • esc: quit"))
+	} else {
+		b.WriteString(helpStyle.Render("tab: next field • ctrl+r: register instead • enter: log in • esc: quit"))
+	}
+
+	return b.String()
+}
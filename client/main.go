@@ -3,30 +3,76 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net"
-	"os"
-	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+)
+
+var (
+	addr          = flag.String("addr", "", "the address to connect to, servers on the local network are listed to pick one when empty")
+	discoverPort  = flag.Int("discover_port", 8421, "port the servers of the local network listen on")
+	serverName    = flag.String("server_name", "", "the name in the server certificate, defaults to the one sent by the server or the host in addr")
+	backlog       = flag.Uint("backlog", 50, "number of past messages to show after logging in")
+	caFingerprint = flag.String("ca_fingerprint", "", "expected SHA-256 fingerprint of the root ca of the server, trusts it without asking")
+	certFile      = flag.String("cert", "", "client certificate signed by the server ca, logs in without a password")
+	keyFile       = flag.String("key", "", "private key of the client certificate")
 )
 
-var addr = flag.String("addr", "localhost:8421", "the address to connect to")
+// defaultRoom is the room every user is in after registering
+const defaultRoom = "general"
+
+// sidebarWidth is the width of the user list next to the messages
+const sidebarWidth = 24
 
 func main() {
 	flag.Parse()
 
-	getCA(*addr, "./certs")
+	if *addr == "" {
+		chosen, err := pickServer(*discoverPort)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if chosen == "" {
+			return
+		}
+		*addr = chosen
+	}
+
+	caPath, hello := getCA(*addr, "./certs", *caFingerprint)
+
+	// connect where the hello server said, unless it did not answer
+	address, name := *addr, *serverName
+	if hello != nil {
+		address = hello.grpcAddress(*addr)
+		if name == "" {
+			name = hello.serverName
+		}
+	}
+
+	if (*certFile == "") != (*keyFile == "") {
+		log.Fatal("-cert and -key must be given together")
+	}
+
+	conn, err := dial(address, caPath, name, *certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	defer conn.close()
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	p := tea.NewProgram(initialModel(conn), tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
@@ -38,16 +84,30 @@ type (
 )
 
 type model struct {
+	conn     *connection
+	loggedIn bool
+	login    loginForm
+
+	room string // the room messages are sent to
+
+	messageStream      pb.ChatService_SubscribeMessageClient
+	announcementStream pb.AnnouncementService_SendAnnouncementClient
+	presenceStream     pb.PresenceService_SubscribePresenceClient
+
+	roster map[string]*pb.RosterEntry // connected users by username
+
 	viewport    viewport.Model
 	messages    []string
 	textarea    textarea.Model
 	senderStyle lipgloss.Style
+	directStyle lipgloss.Style
+	systemStyle lipgloss.Style
 	err         error
 }
 
-func initialModel() model {
+func initialModel(conn *connection) model {
 	ta := textarea.New()
-	ta.Placeholder = "Send a message..."
+	ta.Placeholder = roomPlaceholder(defaultRoom)
 	ta.Focus()
 
 	ta.Prompt = "┃ "
@@ -66,106 +126,404 @@ func initialModel() model {
 
 	ta.ShowLineNumbers = false
 
-	vp := viewport.New(x, y-5)
+	vp := viewport.New(x-sidebarWidth, y-5)
 	vp.SetContent(`Welcome to the chat room!
 Type a message and press Enter to send.`)
 
 	ta.KeyMap.InsertNewline.SetEnabled(false)
 
 	return model{
+		conn:        conn,
+		loggedIn:    conn.certAuth, // the certificate logs in on the first request
+		login:       newLoginForm(),
+		room:        defaultRoom,
+		roster:      make(map[string]*pb.RosterEntry),
 		textarea:    ta,
 		messages:    []string{},
 		viewport:    vp,
 		senderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
+		directStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		systemStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
 		err:         nil,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textarea.Blink
+	if m.loggedIn {
+		return tea.Batch(textarea.Blink, m.subscribe())
+	}
+	return textinput.Blink
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		tiCmd tea.Cmd
-		vpCmd tea.Cmd
-	)
+// subscribe opens the streams of a logged in session.
+func (m model) subscribe() tea.Cmd {
+	return tea.Batch(m.conn.subscribeMessages(uint32(*backlog)), m.conn.subscribeAnnouncements(), m.conn.subscribePresence())
+}
 
-	m.textarea, tiCmd = m.textarea.Update(msg)
-	m.viewport, vpCmd = m.viewport.Update(msg)
+// addLine appends a line to the viewport and scrolls to it.
+func (m *model) addLine(line string) {
+	m.messages = append(m.messages, line)
+	m.viewport.SetContent(strings.Join(m.messages, "\n"))
+	m.viewport.GotoBottom()
+}
 
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
-			fmt.Println(m.textarea.Value())
 			return m, tea.Quit
-		case tea.KeyEnter:
-			m.messages = append(m.messages, m.senderStyle.Render("You: ")+m.textarea.Value())
-			m.viewport.SetContent(strings.Join(m.messages, "\n"))
-			m.textarea.Reset()
-			m.viewport.GotoBottom()
 		}
-	
+
 	case tea.WindowSizeMsg:
 		m.textarea.SetWidth(msg.Width) //* i feel like this is a really goofy way to do this but it works
-		m.viewport.Width = msg.Width
-		m.viewport.Height = msg.Height - 5 
+		m.viewport.Width = msg.Width - sidebarWidth
+		m.viewport.Height = msg.Height - 5
+
+	case loginMsg:
+		m.login.pending = false
+		if msg.status != pb.LoginResponse_SUCCESS {
+			m.login.status = loginStatus(msg.status)
+			return m, nil
+		}
+
+		m.loggedIn = true
+		return m, tea.Batch(textarea.Blink, m.subscribe(), refreshAfter(msg.expires))
+
+	case refreshDueMsg:
+		return m, m.conn.refresh()
+
+	case refreshMsg:
+		return m, refreshAfter(msg.expires)
+
+	case sessionListMsg:
+		m.addLine(m.systemStyle.Render(fmt.Sprintf("%d sessions", len(msg))))
+		for _, session := range msg {
+			line := fmt.Sprintf("  %s %s from %s, active %s, expires %s", shortId(session.Id), clean(session.Client), clean(session.Address),
+				millis(session.LastActive).Format("Jan 2 15:04"), millis(session.Expires).Format("Jan 2 15:04"))
+			if session.Current {
+				line += " (this session)"
+			}
+			m.addLine(m.systemStyle.Render(line))
+		}
+		return m, nil
+
+	case registerMsg:
+		m.login.pending = false
+		if msg.status != pb.RegisterResponse_SUCCESS {
+			m.login.status = registerStatus(msg.status)
+			return m, nil
+		}
+
+		// log in with the new account
+		m.login.register = false
+		m.login.pending = true
+		return m, m.conn.login(m.login.value(usernameField), m.login.inputs[passwordField].Value())
+
+	case messageStreamMsg:
+		m.messageStream = msg.stream
+		return m, waitForMessage(m.messageStream)
+
+	case announcementStreamMsg:
+		m.announcementStream = msg.stream
+		return m, waitForAnnouncement(m.announcementStream)
+
+	case presenceStreamMsg:
+		m.presenceStream = msg.stream
+		return m, waitForPresence(m.presenceStream)
+
+	case presenceMsg:
+		m.applyPresence(msg)
+		return m, waitForPresence(m.presenceStream)
+
+	case chatMsg:
+		sent := time.Unix(0, msg.Timestamp*int64(time.Millisecond)).Format("15:04 ")
+		if msg.Recipient != "" {
+			m.addLine(m.systemStyle.Render(sent) + m.directStyle.Render(clean(msg.Sender)+" -> "+clean(msg.Recipient)+": ") + clean(msg.Message))
+			return m, waitForMessage(m.messageStream)
+		}
+
+		sent = m.systemStyle.Render(sent + "[" + clean(msg.Room) + "] ")
+		if msg.Action {
+			m.addLine(sent + m.senderStyle.Render("* "+clean(msg.Sender)+" ") + clean(msg.Message))
+		} else {
+			m.addLine(sent + m.senderStyle.Render(clean(msg.Sender)+": ") + clean(msg.Message))
+		}
+		return m, waitForMessage(m.messageStream)
+
+	case roomMsg:
+		if msg.status != pb.RoomResponse_OK {
+			m.addLine(m.systemStyle.Render(roomStatus(msg.status, msg.room)))
+			return m, nil
+		}
+
+		switch msg.action {
+		case "create":
+			m.addLine(m.systemStyle.Render("Created room " + msg.room))
+			m.setRoom(msg.room)
+		case "join":
+			m.addLine(m.systemStyle.Render("Joined room " + msg.room))
+			m.setRoom(msg.room)
+		case "leave":
+			m.addLine(m.systemStyle.Render("Left room " + msg.room))
+			if msg.room == m.room {
+				m.setRoom(defaultRoom)
+			}
+		}
+		return m, nil
+
+	case roomListMsg:
+		m.addLine(m.systemStyle.Render(fmt.Sprintf("%d rooms", len(msg))))
+		for _, room := range msg {
+			line := fmt.Sprintf("  %s (%d members)", clean(room.Name), room.Members)
+			if room.Joined {
+				line += " joined"
+			}
+			if room.Topic != "" {
+				line += " - " + clean(room.Topic)
+			}
+			m.addLine(m.systemStyle.Render(line))
+		}
+		return m, nil
+
+	case commandMsg:
+		if msg.Message != "" {
+			m.addLine(m.systemStyle.Render(clean(msg.Message)))
+		}
+		for _, line := range msg.Lines {
+			m.addLine(m.systemStyle.Render("  " + clean(line)))
+		}
+		return m, nil
+
+	case announcementMsg:
+		sent := m.systemStyle.Render(time.Unix(0, msg.Timestamp*int64(time.Millisecond)).Format("15:04 "))
+		label := fmt.Sprintf("[%s] %s: ", strings.ToLower(msg.Severity.String()), clean(msg.Author))
+		m.addLine(sent + severityStyle(msg.Severity).Render(label) + clean(msg.Message))
+		return m, waitForAnnouncement(m.announcementStream)
+
+	case streamClosedMsg:
+		// the server may not offer announcements, that is not worth a warning
+		if status.Code(msg.err) != codes.Unimplemented && status.Code(msg.err) != codes.Canceled {
+			m.addLine(m.systemStyle.Render(fmt.Sprintf("The %s stream was closed: %v", msg.name, clean(status.Convert(msg.err).Message()))))
+		}
+		return m, nil
+
 	// We handle errors just like any other message
 	case errMsg:
 		m.err = msg
+		if !m.loggedIn {
+			m.login.pending = false
+			m.login.status = clean(status.Convert(msg).Message())
+		} else {
+			m.addLine(m.systemStyle.Render("Error: " + clean(status.Convert(msg).Message())))
+		}
 		return m, nil
 	}
 
+	if !m.loggedIn {
+		var cmd tea.Cmd
+		m.login, cmd = m.login.update(msg, m.conn)
+		return m, cmd
+	}
+
+	var (
+		tiCmd   tea.Cmd
+		vpCmd   tea.Cmd
+		sendCmd tea.Cmd
+	)
+
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.Type == tea.KeyEnter {
+		message := strings.TrimSpace(m.textarea.Value())
+		if strings.HasPrefix(message, "/") && len(message) > 1 {
+			sendCmd = m.localCommand(message)
+			if sendCmd == nil {
+				sendCmd = m.conn.sendCommand(m.room, message)
+			}
+		} else if message != "" {
+			sendCmd = m.conn.sendMessage(m.room, message)
+		}
+		m.textarea.Reset()
+		return m, sendCmd
+	}
+
+	m.textarea, tiCmd = m.textarea.Update(msg)
+	m.viewport, vpCmd = m.viewport.Update(msg)
+
 	return m, tea.Batch(tiCmd, vpCmd)
 }
 
-func (m model) View() string {
-	return fmt.Sprintf(
-		"%s\n\n%s",
-		m.viewport.View(),
-		m.textarea.View(),
-	) + "\n\n"
+// localCommand handles the room, direct message and session commands on the client,
+// it returns nil for the commands that run on the server.
+func (m *model) localCommand(line string) tea.Cmd {
+	fields := strings.Fields(line)
+	args := fields[1:]
+
+	switch strings.ToLower(fields[0]) {
+	case "/msg":
+		if len(args) < 2 {
+			m.addLine(m.systemStyle.Render("Usage: /msg <user> <message...>"))
+			return func() tea.Msg { return nil }
+		}
+		return m.conn.sendDirectMessage(args[0], strings.Join(args[1:], " "))
+	case "/rooms":
+		return m.conn.listRooms()
+	case "/create":
+		if len(args) == 0 {
+			m.addLine(m.systemStyle.Render("Usage: /create <room> [topic...]"))
+			return func() tea.Msg { return nil }
+		}
+		return m.conn.roomRequest("create", strings.ToLower(args[0]), strings.Join(args[1:], " "))
+	case "/join":
+		if len(args) != 1 {
+			m.addLine(m.systemStyle.Render("Usage: /join <room>"))
+			return func() tea.Msg { return nil }
+		}
+		return m.conn.roomRequest("join", strings.ToLower(args[0]), "")
+	case "/leave":
+		room := m.room
+		if len(args) > 0 {
+			room = strings.ToLower(args[0])
+		}
+		return m.conn.roomRequest("leave", room, "")
+	case "/sessions":
+		return m.conn.listSessions()
+	case "/revoke":
+		if len(args) != 1 {
+			m.addLine(m.systemStyle.Render("Usage: /revoke <session>"))
+			return func() tea.Msg { return nil }
+		}
+		return m.conn.revokeSession(args[0])
+	case "/room":
+		if len(args) != 1 {
+			m.addLine(m.systemStyle.Render("Current room: " + m.room))
+		} else {
+			m.setRoom(strings.ToLower(args[0]))
+			m.addLine(m.systemStyle.Render("Sending messages to " + m.room))
+		}
+		return func() tea.Msg { return nil }
+	}
+
+	return nil
 }
 
-func getCA(address string, path string) {
-	os.Mkdir(path, 0777)
-	var re = regexp.MustCompile(`(?m)[:]`)
-    
-	filePath := filepath.Join(  //join the path with the file name, replacing the ":" with "_"
-		path, 
-		re.ReplaceAllString(
-			fmt.Sprintf(
-				"ca_cert_%s.pem", 
-				address),
-				"_"))
-	
-	//check if the file already exists and return if it does
-	_, err := os.Stat(filePath)
-    if err == nil {
-        return
-    }
-
-	// Set up a UDP connection to the server.
-	conn, err := net.Dial("udp", address)
-	if err != nil {
-	    log.Fatalf("did not connect: %v", err)
-    }
-
-	conn.Write([]byte("HELLO"))
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second)) //if the server doesn't respond in 5 seconds, the connection is closed
-	
-	//buffer for the ca cert
-	buffer := make([]byte, 2121) // 2121 is the size of all the certificates
-
-    n, err := conn.Read(buffer)
-	if err!= nil {
-		log.Fatalf("failed to read: %v", err)
+// setRoom changes the room messages are sent to
+func (m *model) setRoom(room string) {
+	m.room = room
+	m.textarea.Placeholder = roomPlaceholder(room)
+}
+
+func roomPlaceholder(room string) string {
+	return "Send a message to " + room + "..."
+}
+
+// roomStatus returns the text shown for a failed room request
+func roomStatus(s pb.RoomResponse_Status, room string) string {
+	switch s {
+	case pb.RoomResponse_ROOM_EXISTS:
+		return "Room " + room + " already exists"
+	case pb.RoomResponse_ROOM_NOT_FOUND:
+		return "Room " + room + " does not exist"
+	case pb.RoomResponse_NOT_A_MEMBER:
+		return "You are not in room " + room
+	case pb.RoomResponse_ALREADY_A_MEMBER:
+		return "You are already in room " + room
+	case pb.RoomResponse_INVALID_NAME:
+		return "Room names use up to 32 letters, digits, - and _"
+	}
+	return "The room request failed"
+}
+
+// applyPresence updates the user list with a presence event, the chat only
+// shows users coming and going.
+func (m *model) applyPresence(event *pb.PresenceEvent) {
+	switch event.Type {
+	case pb.PresenceEvent_SNAPSHOT:
+		m.roster = make(map[string]*pb.RosterEntry, len(event.Roster))
+		for _, entry := range event.Roster {
+			m.roster[entry.Username] = entry
+		}
 		return
+	case pb.PresenceEvent_LOGIN:
+		m.addLine(m.systemStyle.Render(clean(event.User.Username) + " joined"))
+		m.roster[event.User.Username] = event.User
+	case pb.PresenceEvent_LOGOUT:
+		m.addLine(m.systemStyle.Render(clean(event.User.Username) + " left"))
+		delete(m.roster, event.User.Username)
+	case pb.PresenceEvent_DISCONNECT:
+		m.addLine(m.systemStyle.Render(clean(event.User.Username) + " was disconnected"))
+		delete(m.roster, event.User.Username)
+	case pb.PresenceEvent_AWAY, pb.PresenceEvent_BACK:
+		m.roster[event.User.Username] = event.User
 	}
+}
 
-    ioutil.WriteFile(filePath, buffer[:n], 0666)
-	if err != nil {
-		log.Fatalf("could not write to file: %v", err)
+// sidebar renders the connected users, away users are dimmed.
+func (m model) sidebar() string {
+	names := make([]string, 0, len(m.roster))
+	for name := range m.roster {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{m.senderStyle.Render(fmt.Sprintf("Online (%d)", len(names)))}
+	for _, name := range names {
+		entry := m.roster[name]
+		name = clean(name)
+		if entry.Admin {
+			name = "@" + name
+		}
+		if entry.Away {
+			lines = append(lines, m.systemStyle.Render(name+" (away)"))
+		} else {
+			lines = append(lines, name)
+		}
 	}
-}
\ No newline at end of file
+
+	return lipgloss.NewStyle().
+		Width(sidebarWidth - 1). // the border takes the last column
+		Height(m.viewport.Height).
+		MaxHeight(m.viewport.Height).
+		PaddingLeft(1).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).
+		Render(strings.Join(lines, "\n"))
+}
+
+// clean drops the runes of a text from the server that are not printable,
+// like the escape starting terminal sequences, so they cannot control the
+// terminal. Other whitespace becomes a space.
+func clean(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsPrint(r):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, text)
+}
+
+// severityStyle returns the style of an announcement label
+func severityStyle(severity pb.Severity) lipgloss.Style {
+	switch severity {
+	case pb.Severity_WARNING:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	case pb.Severity_CRITICAL:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+}
+
+func (m model) View() string {
+	if !m.loggedIn {
+		return m.login.view(*addr) + "\n"
+	}
+
+	return fmt.Sprintf(
+		"%s\n\n%s",
+		lipgloss.JoinHorizontal(lipgloss.Top, m.viewport.View(), m.sidebar()),
+		m.textarea.View(),
+	) + "\n\n"
+}
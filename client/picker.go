@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	discoveryWait     = 1500 * time.Millisecond // time given to the servers to answer
+	discoveryInterval = 5 * time.Second         // between two searches
+)
+
+var selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
+
+type (
+	discoveredMsg struct {
+		servers []discoveredServer
+		err     error
+	}
+	discoverDueMsg struct{}
+)
+
+// picker lists the servers found on the local network and lets the user pick
+// one to connect to.
+type picker struct {
+	port      int // hello port the servers listen on
+	servers   []discoveredServer
+	cursor    int
+	searching bool
+	err       error
+	chosen    string // address of the chosen server, empty if the user quit
+}
+
+// pickServer shows the servers of the local network until the user picks one
+// and returns its address, or an empty string if the user quit.
+func pickServer(port int) (string, error) {
+	m, err := tea.NewProgram(picker{port: port, searching: true}, tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", err
+	}
+	return m.(picker).chosen, nil
+}
+
+func (p picker) Init() tea.Cmd {
+	return p.search()
+}
+
+// search looks for servers in the background.
+func (p picker) search() tea.Cmd {
+	port := p.port
+	return func() tea.Msg {
+		servers, err := discover(port, discoveryWait)
+		return discoveredMsg{servers: servers, err: err}
+	}
+}
+
+func (p picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return p, tea.Quit
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.servers)-1 {
+				p.cursor++
+			}
+		case "r":
+			if !p.searching {
+				p.searching = true
+				return p, p.search()
+			}
+		case "enter":
+			if len(p.servers) > 0 {
+				p.chosen = p.servers[p.cursor].address
+				return p, tea.Quit
+			}
+		}
+
+	case discoveredMsg:
+		// keep the selected server selected when the list changes
+		selected := ""
+		if p.cursor < len(p.servers) {
+			selected = p.servers[p.cursor].address
+		}
+
+		p.searching = false
+		p.err = msg.err
+		p.servers = msg.servers
+		sort.Slice(p.servers, func(i, j int) bool {
+			return p.servers[i].name < p.servers[j].name
+		})
+
+		p.cursor = 0
+		for i, server := range p.servers {
+			if server.address == selected {
+				p.cursor = i
+			}
+		}
+		return p, tea.Tick(discoveryInterval, func(time.Time) tea.Msg { return discoverDueMsg{} })
+
+	case discoverDueMsg:
+		if !p.searching {
+			p.searching = true
+			return p, p.search()
+		}
+	}
+
+	return p, nil
+}
+
+func (p picker) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Servers on the local network"))
+	b.WriteString("\n\n")
+
+	if len(p.servers) == 0 {
+		if p.searching {
+			b.WriteString("Searching...\n")
+		} else {
+			b.WriteString("No servers found, run the client with -addr to connect to another network\n")
+		}
+	}
+	for i, server := range p.servers {
+		users := fmt.Sprintf("%d users", server.clients)
+		if server.maxClients > 0 {
+			users = fmt.Sprintf("%d/%d users", server.clients, server.maxClients)
+		}
+		line := fmt.Sprintf("%-24s %-22s %s", clean(server.name), clean(server.address), users)
+		if server.passwordRequired {
+			line += ", password to register"
+		}
+
+		if i == p.cursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if p.err != nil {
+		b.WriteString(errorStyle.Render("Could not search: "+p.err.Error()) + "\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: select • enter: connect • r: search again • esc: quit"))
+	return b.String() + "\n"
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// knownServersFile keeps the root ca fingerprint trusted for each server address
+const knownServersFile = "known_servers"
+
+// getCA gets the ca chain of the server over hello and returns the path
+// where it is stored, with what the server said about itself when it
+// answered. The first time a server is seen the fingerprint of its root must
+// match expected, or be confirmed by the user, and is then pinned. A server
+// whose root no longer matches the pin is refused.
+func getCA(address string, path string, expected string) (string, *helloInfo) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		log.Fatalf("could not create %v: %v", path, err)
+	}
+
+	// the file name is the address with the ":" replaced by "_"
+	filePath := filepath.Join(path, "ca_cert_"+strings.Replace(address, ":", "_", -1)+".pem")
+	knownPath := filepath.Join(path, knownServersFile)
+
+	known, err := readKnownServers(knownPath)
+	if err != nil {
+		log.Fatalf("could not read %v: %v", knownPath, err)
+	}
+	pinned := known[address]
+
+	hello, err := sayHello(address)
+	if err != nil {
+		// the hello server may be unreachable, the pinned chain is still good
+		if cached, cacheErr := ioutil.ReadFile(filePath); cacheErr == nil && pinned != "" {
+			if fingerprint, _ := utils.CertFingerprint(cached); utils.SameFingerprint(fingerprint, pinned) {
+				return filePath, nil
+			}
+		}
+		log.Fatalf("could not get the ca chain of %v: %v", address, err)
+	}
+
+	// the intermediates were checked against the root when the chain was
+	// reassembled, the root is what gets pinned
+	fingerprint := hello.chain.Fingerprint()
+
+	switch {
+	case expected != "":
+		// an expected fingerprint also accepts a new ca the user was told about
+		if !utils.SameFingerprint(fingerprint, expected) {
+			log.Fatalf("the root ca of %v has fingerprint %v, not the expected %v", address, fingerprint, expected)
+		}
+	case pinned == "":
+		if !confirmCA(address, fingerprint) {
+			log.Fatalf("the root ca of %v was not trusted", address)
+		}
+	case !utils.SameFingerprint(fingerprint, pinned):
+		warnChangedCA(address, fingerprint, pinned, knownPath)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(filePath, hello.chain.PEM(), 0644); err != nil {
+		log.Fatalf("could not write to file: %v", err)
+	}
+	if !utils.SameFingerprint(fingerprint, pinned) {
+		known[address] = fingerprint
+		if err := writeKnownServers(knownPath, known); err != nil {
+			log.Fatalf("could not write %v: %v", knownPath, err)
+		}
+	}
+
+	return filePath, hello
+}
+
+// confirmCA shows the fingerprint of a new server and asks the user if it is
+// the expected one. Without a terminal to ask there is no way to trust it.
+func confirmCA(address string, fingerprint string) bool {
+	fmt.Fprintf(os.Stderr, "The server %v is not known yet.\n", address)
+	fmt.Fprintf(os.Stderr, "Its root CA certificate has the SHA-256 fingerprint\n\n  %v\n\n", fingerprint)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "Check it with the server operator and run again with -ca_fingerprint.")
+		return false
+	}
+
+	fmt.Fprint(os.Stderr, "Check it with the server operator. Trust it? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// warnChangedCA tells the user the ca of a known server changed, which is
+// what an attacker answering the hello would look like.
+func warnChangedCA(address string, fingerprint string, pinned string, knownPath string) {
+	fmt.Fprintf(os.Stderr, `@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+@   WARNING: THE CA CERTIFICATE OF THE SERVER HAS CHANGED! @
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+Someone could be impersonating %v, or its operator replaced the ca.
+
+Trusted fingerprint:  %v
+Received fingerprint: %v
+
+The connection was refused. If the operator confirms the new fingerprint,
+run again with -ca_fingerprint or remove the line of %v in %v.
+`, address, pinned, fingerprint, address, knownPath)
+}
+
+// readKnownServers reads the address and fingerprint pairs of the known
+// servers file, a missing file has no servers.
+func readKnownServers(path string) (map[string]string, error) {
+	known := make(map[string]string)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		known[fields[0]] = fields[1]
+	}
+
+	return known, nil
+}
+
+func writeKnownServers(path string, known map[string]string) error {
+	addresses := make([]string, 0, len(known))
+	for address := range known {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var b strings.Builder
+	b.WriteString("# address and SHA-256 fingerprint of the trusted root ca of each server\n")
+	for _, address := range addresses {
+		fmt.Fprintf(&b, "%v %v\n", address, known[address])
+	}
+
+	return ioutil.WriteFile(path, []byte(b.String()), 0600)
+}
@@ -7,6 +7,8 @@ require (
 	github.com/charmbracelet/bubbletea v0.23.1
 	github.com/charmbracelet/lipgloss v0.5.0
 	golang.org/x/crypto v0.3.0
+	golang.org/x/net v0.2.0
+	golang.org/x/term v0.2.0
 	google.golang.org/genproto v0.0.0-20221118155620-16455021b5e6 // indirect
 	google.golang.org/grpc v1.50.1
 	google.golang.org/protobuf v1.28.1
@@ -20,11 +20,111 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Severity int32
+
+const (
+	Severity_INFO     Severity = 0
+	Severity_WARNING  Severity = 1
+	Severity_CRITICAL Severity = 2
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "INFO",
+		1: "WARNING",
+		2: "CRITICAL",
+	}
+	Severity_value = map[string]int32{
+		"INFO":     0,
+		"WARNING":  1,
+		"CRITICAL": 2,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[0].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[0]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{0}
+}
+
+type Role int32
+
+const (
+	Role_EVERYONE Role = 0
+	Role_ADMINS   Role = 1
+	Role_USERS    Role = 2 // users that are not admins
+)
+
+// Enum value maps for Role.
+var (
+	Role_name = map[int32]string{
+		0: "EVERYONE",
+		1: "ADMINS",
+		2: "USERS",
+	}
+	Role_value = map[string]int32{
+		"EVERYONE": 0,
+		"ADMINS":   1,
+		"USERS":    2,
+	}
+)
+
+func (x Role) Enum() *Role {
+	p := new(Role)
+	*p = x
+	return p
+}
+
+func (x Role) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Role) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[1].Descriptor()
+}
+
+func (Role) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[1]
+}
+
+func (x Role) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Role.Descriptor instead.
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{1}
+}
+
 type MessageR_Status int32
 
 const (
-	MessageR_OK    MessageR_Status = 0
-	MessageR_ERROR MessageR_Status = 1
+	MessageR_OK             MessageR_Status = 0
+	MessageR_ERROR          MessageR_Status = 1
+	MessageR_ROOM_NOT_FOUND MessageR_Status = 2
+	MessageR_NOT_A_MEMBER   MessageR_Status = 3
 )
 
 // Enum value maps for MessageR_Status.
@@ -32,10 +132,14 @@ var (
 	MessageR_Status_name = map[int32]string{
 		0: "OK",
 		1: "ERROR",
+		2: "ROOM_NOT_FOUND",
+		3: "NOT_A_MEMBER",
 	}
 	MessageR_Status_value = map[string]int32{
-		"OK":    0,
-		"ERROR": 1,
+		"OK":             0,
+		"ERROR":          1,
+		"ROOM_NOT_FOUND": 2,
+		"NOT_A_MEMBER":   3,
 	}
 )
 
@@ -50,11 +154,11 @@ func (x MessageR_Status) String() string {
 }
 
 func (MessageR_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_communication_proto_enumTypes[0].Descriptor()
+	return file_proto_communication_proto_enumTypes[2].Descriptor()
 }
 
 func (MessageR_Status) Type() protoreflect.EnumType {
-	return &file_proto_communication_proto_enumTypes[0]
+	return &file_proto_communication_proto_enumTypes[2]
 }
 
 func (x MessageR_Status) Number() protoreflect.EnumNumber {
@@ -66,6 +170,122 @@ func (MessageR_Status) EnumDescriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{1, 0}
 }
 
+type DirectMessageR_Status int32
+
+const (
+	DirectMessageR_OK           DirectMessageR_Status = 0
+	DirectMessageR_ERROR        DirectMessageR_Status = 1
+	DirectMessageR_UNKNOWN_USER DirectMessageR_Status = 2
+	DirectMessageR_QUEUED       DirectMessageR_Status = 3 // the recipient is not connected, it gets the message when it does
+	DirectMessageR_QUEUE_FULL   DirectMessageR_Status = 4
+)
+
+// Enum value maps for DirectMessageR_Status.
+var (
+	DirectMessageR_Status_name = map[int32]string{
+		0: "OK",
+		1: "ERROR",
+		2: "UNKNOWN_USER",
+		3: "QUEUED",
+		4: "QUEUE_FULL",
+	}
+	DirectMessageR_Status_value = map[string]int32{
+		"OK":           0,
+		"ERROR":        1,
+		"UNKNOWN_USER": 2,
+		"QUEUED":       3,
+		"QUEUE_FULL":   4,
+	}
+)
+
+func (x DirectMessageR_Status) Enum() *DirectMessageR_Status {
+	p := new(DirectMessageR_Status)
+	*p = x
+	return p
+}
+
+func (x DirectMessageR_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DirectMessageR_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[3].Descriptor()
+}
+
+func (DirectMessageR_Status) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[3]
+}
+
+func (x DirectMessageR_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DirectMessageR_Status.Descriptor instead.
+func (DirectMessageR_Status) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type RoomResponse_Status int32
+
+const (
+	RoomResponse_OK               RoomResponse_Status = 0
+	RoomResponse_ERROR            RoomResponse_Status = 1
+	RoomResponse_ROOM_EXISTS      RoomResponse_Status = 2
+	RoomResponse_ROOM_NOT_FOUND   RoomResponse_Status = 3
+	RoomResponse_NOT_A_MEMBER     RoomResponse_Status = 4
+	RoomResponse_ALREADY_A_MEMBER RoomResponse_Status = 5
+	RoomResponse_INVALID_NAME     RoomResponse_Status = 6
+)
+
+// Enum value maps for RoomResponse_Status.
+var (
+	RoomResponse_Status_name = map[int32]string{
+		0: "OK",
+		1: "ERROR",
+		2: "ROOM_EXISTS",
+		3: "ROOM_NOT_FOUND",
+		4: "NOT_A_MEMBER",
+		5: "ALREADY_A_MEMBER",
+		6: "INVALID_NAME",
+	}
+	RoomResponse_Status_value = map[string]int32{
+		"OK":               0,
+		"ERROR":            1,
+		"ROOM_EXISTS":      2,
+		"ROOM_NOT_FOUND":   3,
+		"NOT_A_MEMBER":     4,
+		"ALREADY_A_MEMBER": 5,
+		"INVALID_NAME":     6,
+	}
+)
+
+func (x RoomResponse_Status) Enum() *RoomResponse_Status {
+	p := new(RoomResponse_Status)
+	*p = x
+	return p
+}
+
+func (x RoomResponse_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RoomResponse_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[4].Descriptor()
+}
+
+func (RoomResponse_Status) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[4]
+}
+
+func (x RoomResponse_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RoomResponse_Status.Descriptor instead.
+func (RoomResponse_Status) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{5, 0}
+}
+
 type SubMessage_Status int32
 
 const (
@@ -96,11 +316,11 @@ func (x SubMessage_Status) String() string {
 }
 
 func (SubMessage_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_communication_proto_enumTypes[1].Descriptor()
+	return file_proto_communication_proto_enumTypes[5].Descriptor()
 }
 
 func (SubMessage_Status) Type() protoreflect.EnumType {
-	return &file_proto_communication_proto_enumTypes[1]
+	return &file_proto_communication_proto_enumTypes[5]
 }
 
 func (x SubMessage_Status) Number() protoreflect.EnumNumber {
@@ -109,14 +329,17 @@ func (x SubMessage_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use SubMessage_Status.Descriptor instead.
 func (SubMessage_Status) EnumDescriptor() ([]byte, []int) {
-	return file_proto_communication_proto_rawDescGZIP(), []int{3, 0}
+	return file_proto_communication_proto_rawDescGZIP(), []int{10, 0}
 }
 
 type CommandR_Status int32
 
 const (
-	CommandR_OK    CommandR_Status = 0
-	CommandR_ERROR CommandR_Status = 1
+	CommandR_OK                CommandR_Status = 0
+	CommandR_ERROR             CommandR_Status = 1
+	CommandR_UNKNOWN_COMMAND   CommandR_Status = 2
+	CommandR_INVALID_ARGUMENTS CommandR_Status = 3
+	CommandR_PERMISSION_DENIED CommandR_Status = 4
 )
 
 // Enum value maps for CommandR_Status.
@@ -124,10 +347,16 @@ var (
 	CommandR_Status_name = map[int32]string{
 		0: "OK",
 		1: "ERROR",
+		2: "UNKNOWN_COMMAND",
+		3: "INVALID_ARGUMENTS",
+		4: "PERMISSION_DENIED",
 	}
 	CommandR_Status_value = map[string]int32{
-		"OK":    0,
-		"ERROR": 1,
+		"OK":                0,
+		"ERROR":             1,
+		"UNKNOWN_COMMAND":   2,
+		"INVALID_ARGUMENTS": 3,
+		"PERMISSION_DENIED": 4,
 	}
 )
 
@@ -142,11 +371,11 @@ func (x CommandR_Status) String() string {
 }
 
 func (CommandR_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_communication_proto_enumTypes[2].Descriptor()
+	return file_proto_communication_proto_enumTypes[6].Descriptor()
 }
 
 func (CommandR_Status) Type() protoreflect.EnumType {
-	return &file_proto_communication_proto_enumTypes[2]
+	return &file_proto_communication_proto_enumTypes[6]
 }
 
 func (x CommandR_Status) Number() protoreflect.EnumNumber {
@@ -155,7 +384,59 @@ func (x CommandR_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use CommandR_Status.Descriptor instead.
 func (CommandR_Status) EnumDescriptor() ([]byte, []int) {
-	return file_proto_communication_proto_rawDescGZIP(), []int{5, 0}
+	return file_proto_communication_proto_rawDescGZIP(), []int{12, 0}
+}
+
+type AnnouncementR_Status int32
+
+const (
+	AnnouncementR_OK                AnnouncementR_Status = 0
+	AnnouncementR_ERROR             AnnouncementR_Status = 1
+	AnnouncementR_PERMISSION_DENIED AnnouncementR_Status = 2
+	AnnouncementR_UNKNOWN_USER      AnnouncementR_Status = 3
+)
+
+// Enum value maps for AnnouncementR_Status.
+var (
+	AnnouncementR_Status_name = map[int32]string{
+		0: "OK",
+		1: "ERROR",
+		2: "PERMISSION_DENIED",
+		3: "UNKNOWN_USER",
+	}
+	AnnouncementR_Status_value = map[string]int32{
+		"OK":                0,
+		"ERROR":             1,
+		"PERMISSION_DENIED": 2,
+		"UNKNOWN_USER":      3,
+	}
+)
+
+func (x AnnouncementR_Status) Enum() *AnnouncementR_Status {
+	p := new(AnnouncementR_Status)
+	*p = x
+	return p
+}
+
+func (x AnnouncementR_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AnnouncementR_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[7].Descriptor()
+}
+
+func (AnnouncementR_Status) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[7]
+}
+
+func (x AnnouncementR_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AnnouncementR_Status.Descriptor instead.
+func (AnnouncementR_Status) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{14, 0}
 }
 
 type SubAnnouncement_Status int32
@@ -188,11 +469,11 @@ func (x SubAnnouncement_Status) String() string {
 }
 
 func (SubAnnouncement_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_communication_proto_enumTypes[3].Descriptor()
+	return file_proto_communication_proto_enumTypes[8].Descriptor()
 }
 
 func (SubAnnouncement_Status) Type() protoreflect.EnumType {
-	return &file_proto_communication_proto_enumTypes[3]
+	return &file_proto_communication_proto_enumTypes[8]
 }
 
 func (x SubAnnouncement_Status) Number() protoreflect.EnumNumber {
@@ -201,7 +482,65 @@ func (x SubAnnouncement_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use SubAnnouncement_Status.Descriptor instead.
 func (SubAnnouncement_Status) EnumDescriptor() ([]byte, []int) {
-	return file_proto_communication_proto_rawDescGZIP(), []int{6, 0}
+	return file_proto_communication_proto_rawDescGZIP(), []int{15, 0}
+}
+
+type PresenceEvent_Type int32
+
+const (
+	PresenceEvent_SNAPSHOT   PresenceEvent_Type = 0 // the connected users, first event of the stream
+	PresenceEvent_LOGIN      PresenceEvent_Type = 1
+	PresenceEvent_LOGOUT     PresenceEvent_Type = 2
+	PresenceEvent_DISCONNECT PresenceEvent_Type = 3 // the session was ended by the server, like a kick
+	PresenceEvent_AWAY       PresenceEvent_Type = 4
+	PresenceEvent_BACK       PresenceEvent_Type = 5
+)
+
+// Enum value maps for PresenceEvent_Type.
+var (
+	PresenceEvent_Type_name = map[int32]string{
+		0: "SNAPSHOT",
+		1: "LOGIN",
+		2: "LOGOUT",
+		3: "DISCONNECT",
+		4: "AWAY",
+		5: "BACK",
+	}
+	PresenceEvent_Type_value = map[string]int32{
+		"SNAPSHOT":   0,
+		"LOGIN":      1,
+		"LOGOUT":     2,
+		"DISCONNECT": 3,
+		"AWAY":       4,
+		"BACK":       5,
+	}
+)
+
+func (x PresenceEvent_Type) Enum() *PresenceEvent_Type {
+	p := new(PresenceEvent_Type)
+	*p = x
+	return p
+}
+
+func (x PresenceEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PresenceEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_communication_proto_enumTypes[9].Descriptor()
+}
+
+func (PresenceEvent_Type) Type() protoreflect.EnumType {
+	return &file_proto_communication_proto_enumTypes[9]
+}
+
+func (x PresenceEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PresenceEvent_Type.Descriptor instead.
+func (PresenceEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{17, 0}
 }
 
 type MessageS struct {
@@ -209,8 +548,8 @@ type MessageS struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Token   string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
 	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Room    string `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"` // the default room when empty
 }
 
 func (x *MessageS) Reset() {
@@ -245,16 +584,16 @@ func (*MessageS) Descriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *MessageS) GetToken() string {
+func (x *MessageS) GetMessage() string {
 	if x != nil {
-		return x.Token
+		return x.Message
 	}
 	return ""
 }
 
-func (x *MessageS) GetMessage() string {
+func (x *MessageS) GetRoom() string {
 	if x != nil {
-		return x.Message
+		return x.Room
 	}
 	return ""
 }
@@ -306,16 +645,17 @@ func (x *MessageR) GetStatus() MessageR_Status {
 	return MessageR_OK
 }
 
-type SubRequest struct {
+type DirectMessageS struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Recipient string `protobuf:"bytes,1,opt,name=recipient,proto3" json:"recipient,omitempty"` // username
+	Message   string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 }
 
-func (x *SubRequest) Reset() {
-	*x = SubRequest{}
+func (x *DirectMessageS) Reset() {
+	*x = DirectMessageS{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_proto_communication_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -323,13 +663,13 @@ func (x *SubRequest) Reset() {
 	}
 }
 
-func (x *SubRequest) String() string {
+func (x *DirectMessageS) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubRequest) ProtoMessage() {}
+func (*DirectMessageS) ProtoMessage() {}
 
-func (x *SubRequest) ProtoReflect() protoreflect.Message {
+func (x *DirectMessageS) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_communication_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -341,29 +681,35 @@ func (x *SubRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubRequest.ProtoReflect.Descriptor instead.
-func (*SubRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DirectMessageS.ProtoReflect.Descriptor instead.
+func (*DirectMessageS) Descriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *SubRequest) GetToken() string {
+func (x *DirectMessageS) GetRecipient() string {
 	if x != nil {
-		return x.Token
+		return x.Recipient
 	}
 	return ""
 }
 
-type SubMessage struct {
+func (x *DirectMessageS) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DirectMessageR struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  SubMessage_Status `protobuf:"varint,1,opt,name=status,proto3,enum=SubMessage_Status" json:"status,omitempty"`
-	Message string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status DirectMessageR_Status `protobuf:"varint,1,opt,name=status,proto3,enum=DirectMessageR_Status" json:"status,omitempty"`
 }
 
-func (x *SubMessage) Reset() {
-	*x = SubMessage{}
+func (x *DirectMessageR) Reset() {
+	*x = DirectMessageR{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_proto_communication_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -371,13 +717,13 @@ func (x *SubMessage) Reset() {
 	}
 }
 
-func (x *SubMessage) String() string {
+func (x *DirectMessageR) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubMessage) ProtoMessage() {}
+func (*DirectMessageR) ProtoMessage() {}
 
-func (x *SubMessage) ProtoReflect() protoreflect.Message {
+func (x *DirectMessageR) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_communication_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -389,37 +735,29 @@ func (x *SubMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubMessage.ProtoReflect.Descriptor instead.
-func (*SubMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use DirectMessageR.ProtoReflect.Descriptor instead.
+func (*DirectMessageR) Descriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *SubMessage) GetStatus() SubMessage_Status {
+func (x *DirectMessageR) GetStatus() DirectMessageR_Status {
 	if x != nil {
 		return x.Status
 	}
-	return SubMessage_OK
-}
-
-func (x *SubMessage) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
+	return DirectMessageR_OK
 }
 
-type CommandS struct {
+type RoomRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Token   string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
-	Command string   `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
-	Args    []string `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	Room  string `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Topic string `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"` // only used by CreateRoom
 }
 
-func (x *CommandS) Reset() {
-	*x = CommandS{}
+func (x *RoomRequest) Reset() {
+	*x = RoomRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_proto_communication_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -427,13 +765,13 @@ func (x *CommandS) Reset() {
 	}
 }
 
-func (x *CommandS) String() string {
+func (x *RoomRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommandS) ProtoMessage() {}
+func (*RoomRequest) ProtoMessage() {}
 
-func (x *CommandS) ProtoReflect() protoreflect.Message {
+func (x *RoomRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_communication_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -445,43 +783,35 @@ func (x *CommandS) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommandS.ProtoReflect.Descriptor instead.
-func (*CommandS) Descriptor() ([]byte, []int) {
+// Deprecated: Use RoomRequest.ProtoReflect.Descriptor instead.
+func (*RoomRequest) Descriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *CommandS) GetToken() string {
+func (x *RoomRequest) GetRoom() string {
 	if x != nil {
-		return x.Token
+		return x.Room
 	}
 	return ""
 }
 
-func (x *CommandS) GetCommand() string {
+func (x *RoomRequest) GetTopic() string {
 	if x != nil {
-		return x.Command
+		return x.Topic
 	}
 	return ""
 }
 
-func (x *CommandS) GetArgs() []string {
-	if x != nil {
-		return x.Args
-	}
-	return nil
-}
-
-type CommandR struct {
+type RoomResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  CommandR_Status `protobuf:"varint,1,opt,name=status,proto3,enum=CommandR_Status" json:"status,omitempty"`
-	Message string          `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status RoomResponse_Status `protobuf:"varint,1,opt,name=status,proto3,enum=RoomResponse_Status" json:"status,omitempty"`
 }
 
-func (x *CommandR) Reset() {
-	*x = CommandR{}
+func (x *RoomResponse) Reset() {
+	*x = RoomResponse{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_proto_communication_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -489,13 +819,13 @@ func (x *CommandR) Reset() {
 	}
 }
 
-func (x *CommandR) String() string {
+func (x *RoomResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommandR) ProtoMessage() {}
+func (*RoomResponse) ProtoMessage() {}
 
-func (x *CommandR) ProtoReflect() protoreflect.Message {
+func (x *RoomResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_communication_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -507,38 +837,602 @@ func (x *CommandR) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommandR.ProtoReflect.Descriptor instead.
-func (*CommandR) Descriptor() ([]byte, []int) {
+// Deprecated: Use RoomResponse.ProtoReflect.Descriptor instead.
+func (*RoomResponse) Descriptor() ([]byte, []int) {
 	return file_proto_communication_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *CommandR) GetStatus() CommandR_Status {
+func (x *RoomResponse) GetStatus() RoomResponse_Status {
 	if x != nil {
 		return x.Status
 	}
-	return CommandR_OK
-}
-
-func (x *CommandR) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
+	return RoomResponse_OK
 }
 
-type SubAnnouncement struct {
+type ListRoomsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListRoomsRequest) Reset() {
+	*x = ListRoomsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListRoomsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomsRequest) ProtoMessage() {}
+
+func (x *ListRoomsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomsRequest.ProtoReflect.Descriptor instead.
+func (*ListRoomsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{6}
+}
+
+type RoomInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Topic   string `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Members uint32 `protobuf:"varint,3,opt,name=members,proto3" json:"members,omitempty"`
+	Joined  bool   `protobuf:"varint,4,opt,name=joined,proto3" json:"joined,omitempty"` // the caller is a member
+}
+
+func (x *RoomInfo) Reset() {
+	*x = RoomInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RoomInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomInfo) ProtoMessage() {}
+
+func (x *RoomInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomInfo.ProtoReflect.Descriptor instead.
+func (*RoomInfo) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RoomInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetMembers() uint32 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *RoomInfo) GetJoined() bool {
+	if x != nil {
+		return x.Joined
+	}
+	return false
+}
+
+type ListRoomsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rooms []*RoomInfo `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+}
+
+func (x *ListRoomsResponse) Reset() {
+	*x = ListRoomsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListRoomsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomsResponse) ProtoMessage() {}
+
+func (x *ListRoomsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomsResponse.ProtoReflect.Descriptor instead.
+func (*ListRoomsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListRoomsResponse) GetRooms() []*RoomInfo {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type SubRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Backlog uint32 `protobuf:"varint,1,opt,name=backlog,proto3" json:"backlog,omitempty"`                // number of past messages to replay, 0 for none
+	SinceId uint64 `protobuf:"varint,2,opt,name=since_id,json=sinceId,proto3" json:"since_id,omitempty"` // only replay messages newer than this id
+}
+
+func (x *SubRequest) Reset() {
+	*x = SubRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubRequest) ProtoMessage() {}
+
+func (x *SubRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubRequest.ProtoReflect.Descriptor instead.
+func (*SubRequest) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubRequest) GetBacklog() uint32 {
+	if x != nil {
+		return x.Backlog
+	}
+	return 0
+}
+
+func (x *SubRequest) GetSinceId() uint64 {
+	if x != nil {
+		return x.SinceId
+	}
+	return 0
+}
+
+type SubMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status    SubMessage_Status `protobuf:"varint,1,opt,name=status,proto3,enum=SubMessage_Status" json:"status,omitempty"`
+	Message   string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Sender    string            `protobuf:"bytes,3,opt,name=sender,proto3" json:"sender,omitempty"`
+	Id        uint64            `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`
+	Timestamp int64             `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix time in milliseconds
+	Action    bool              `protobuf:"varint,6,opt,name=action,proto3" json:"action,omitempty"`       // sent with /me
+	Room      string            `protobuf:"bytes,7,opt,name=room,proto3" json:"room,omitempty"`
+	Recipient string            `protobuf:"bytes,8,opt,name=recipient,proto3" json:"recipient,omitempty"` // set on direct messages, which have no room
+}
+
+func (x *SubMessage) Reset() {
+	*x = SubMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubMessage) ProtoMessage() {}
+
+func (x *SubMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubMessage.ProtoReflect.Descriptor instead.
+func (*SubMessage) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubMessage) GetStatus() SubMessage_Status {
+	if x != nil {
+		return x.Status
+	}
+	return SubMessage_OK
+}
+
+func (x *SubMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SubMessage) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *SubMessage) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SubMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SubMessage) GetAction() bool {
+	if x != nil {
+		return x.Action
+	}
+	return false
+}
+
+func (x *SubMessage) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *SubMessage) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+type CommandS struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Command string   `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	Args    []string `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	Room    string   `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"` // room the command was typed in, the default room when empty
+}
+
+func (x *CommandS) Reset() {
+	*x = CommandS{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommandS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandS) ProtoMessage() {}
+
+func (x *CommandS) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandS.ProtoReflect.Descriptor instead.
+func (*CommandS) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CommandS) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *CommandS) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *CommandS) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type CommandR struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  CommandR_Status `protobuf:"varint,1,opt,name=status,proto3,enum=CommandR_Status" json:"status,omitempty"`
+	Message string          `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Lines   []string        `protobuf:"bytes,3,rep,name=lines,proto3" json:"lines,omitempty"` // command output, one entry per line
+}
+
+func (x *CommandR) Reset() {
+	*x = CommandR{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommandR) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandR) ProtoMessage() {}
+
+func (x *CommandR) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandR.ProtoReflect.Descriptor instead.
+func (*CommandR) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CommandR) GetStatus() CommandR_Status {
+	if x != nil {
+		return x.Status
+	}
+	return CommandR_OK
+}
+
+func (x *CommandR) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CommandR) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type AnnouncementS struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message  string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Severity Severity `protobuf:"varint,2,opt,name=severity,proto3,enum=Severity" json:"severity,omitempty"`
+	// an announcement reaches the listed users and the users in role, EVERYONE
+	// only applies when no users are listed
+	Usernames []string `protobuf:"bytes,3,rep,name=usernames,proto3" json:"usernames,omitempty"`
+	Role      Role     `protobuf:"varint,4,opt,name=role,proto3,enum=Role" json:"role,omitempty"`
+}
+
+func (x *AnnouncementS) Reset() {
+	*x = AnnouncementS{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnnouncementS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnouncementS) ProtoMessage() {}
+
+func (x *AnnouncementS) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnouncementS.ProtoReflect.Descriptor instead.
+func (*AnnouncementS) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AnnouncementS) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AnnouncementS) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_INFO
+}
+
+func (x *AnnouncementS) GetUsernames() []string {
+	if x != nil {
+		return x.Usernames
+	}
+	return nil
+}
+
+func (x *AnnouncementS) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_EVERYONE
+}
+
+type AnnouncementR struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status     AnnouncementR_Status `protobuf:"varint,1,opt,name=status,proto3,enum=AnnouncementR_Status" json:"status,omitempty"`
+	Recipients uint32               `protobuf:"varint,2,opt,name=recipients,proto3" json:"recipients,omitempty"` // number of open streams that received it
+}
+
+func (x *AnnouncementR) Reset() {
+	*x = AnnouncementR{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnnouncementR) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnouncementR) ProtoMessage() {}
+
+func (x *AnnouncementR) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnouncementR.ProtoReflect.Descriptor instead.
+func (*AnnouncementR) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AnnouncementR) GetStatus() AnnouncementR_Status {
+	if x != nil {
+		return x.Status
+	}
+	return AnnouncementR_OK
+}
+
+func (x *AnnouncementR) GetRecipients() uint32 {
+	if x != nil {
+		return x.Recipients
+	}
+	return 0
+}
+
+type SubAnnouncement struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status  SubAnnouncement_Status `protobuf:"varint,1,opt,name=status,proto3,enum=SubAnnouncement_Status" json:"status,omitempty"`
-	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status    SubAnnouncement_Status `protobuf:"varint,1,opt,name=status,proto3,enum=SubAnnouncement_Status" json:"status,omitempty"`
+	Message   string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Severity  Severity               `protobuf:"varint,3,opt,name=severity,proto3,enum=Severity" json:"severity,omitempty"`
+	Author    string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	Timestamp int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix time in milliseconds
 }
 
 func (x *SubAnnouncement) Reset() {
 	*x = SubAnnouncement{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_communication_proto_msgTypes[6]
+		mi := &file_proto_communication_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -551,7 +1445,7 @@ func (x *SubAnnouncement) String() string {
 func (*SubAnnouncement) ProtoMessage() {}
 
 func (x *SubAnnouncement) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_communication_proto_msgTypes[6]
+	mi := &file_proto_communication_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,7 +1458,7 @@ func (x *SubAnnouncement) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubAnnouncement.ProtoReflect.Descriptor instead.
 func (*SubAnnouncement) Descriptor() ([]byte, []int) {
-	return file_proto_communication_proto_rawDescGZIP(), []int{6}
+	return file_proto_communication_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *SubAnnouncement) GetStatus() SubAnnouncement_Status {
@@ -581,65 +1475,353 @@ func (x *SubAnnouncement) GetMessage() string {
 	return ""
 }
 
+func (x *SubAnnouncement) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_INFO
+}
+
+func (x *SubAnnouncement) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *SubAnnouncement) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type RosterEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Away     bool   `protobuf:"varint,2,opt,name=away,proto3" json:"away,omitempty"`
+	Admin    bool   `protobuf:"varint,3,opt,name=admin,proto3" json:"admin,omitempty"`
+}
+
+func (x *RosterEntry) Reset() {
+	*x = RosterEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RosterEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RosterEntry) ProtoMessage() {}
+
+func (x *RosterEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RosterEntry.ProtoReflect.Descriptor instead.
+func (*RosterEntry) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RosterEntry) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *RosterEntry) GetAway() bool {
+	if x != nil {
+		return x.Away
+	}
+	return false
+}
+
+func (x *RosterEntry) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+type PresenceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      PresenceEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=PresenceEvent_Type" json:"type,omitempty"`
+	User      *RosterEntry       `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`            // the user the event is about, unset for SNAPSHOT
+	Roster    []*RosterEntry     `protobuf:"bytes,3,rep,name=roster,proto3" json:"roster,omitempty"`        // only set for SNAPSHOT
+	Timestamp int64              `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix time in milliseconds
+}
+
+func (x *PresenceEvent) Reset() {
+	*x = PresenceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_communication_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PresenceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceEvent) ProtoMessage() {}
+
+func (x *PresenceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_communication_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceEvent.ProtoReflect.Descriptor instead.
+func (*PresenceEvent) Descriptor() ([]byte, []int) {
+	return file_proto_communication_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PresenceEvent) GetType() PresenceEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PresenceEvent_SNAPSHOT
+}
+
+func (x *PresenceEvent) GetUser() *RosterEntry {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *PresenceEvent) GetRoster() []*RosterEntry {
+	if x != nil {
+		return x.Roster
+	}
+	return nil
+}
+
+func (x *PresenceEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
 var File_proto_communication_proto protoreflect.FileDescriptor
 
 var file_proto_communication_proto_rawDesc = []byte{
 	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6f, 0x6d, 0x6d, 0x75, 0x6e, 0x69, 0x63,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x3a, 0x0a, 0x08, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x18, 0x0a,
-	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x51, 0x0a, 0x08, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x52, 0x12, 0x28, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x2e, 0x53,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x1b, 0x0a,
-	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12,
-	0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x22, 0x22, 0x0a, 0x0a, 0x53, 0x75,
-	0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x6f,
-	0x0a, 0x0a, 0x53, 0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2a, 0x0a, 0x06,
-	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x53,
-	0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x22, 0x1b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02,
-	0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x22,
-	0x4e, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x12, 0x14, 0x0a, 0x05, 0x74,
-	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65,
-	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61,
-	0x72, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x22,
-	0x6b, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x12, 0x28, 0x0a, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x43, 0x6f,
-	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22,
-	0x1b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10,
-	0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x22, 0x79, 0x0a, 0x0f,
-	0x53, 0x75, 0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12,
-	0x2f, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
-	0x17, 0x2e, 0x53, 0x75, 0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e,
-	0x74, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x1b, 0x0a, 0x06, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05,
-	0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x32, 0x66, 0x0a, 0x0b, 0x43, 0x68, 0x61, 0x74, 0x53,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x09, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53,
-	0x1a, 0x09, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x22, 0x00, 0x12, 0x30, 0x0a,
-	0x10, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x12, 0x0b, 0x2e, 0x53, 0x75, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b,
-	0x2e, 0x53, 0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x30, 0x01, 0x32,
-	0x37, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x12, 0x25, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
-	0x12, 0x09, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x1a, 0x09, 0x2e, 0x43, 0x6f,
-	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x22, 0x00, 0x32, 0x4c, 0x0a, 0x13, 0x41, 0x6e, 0x6e, 0x6f,
-	0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x35, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d,
-	0x65, 0x6e, 0x74, 0x12, 0x0b, 0x2e, 0x53, 0x75, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x10, 0x2e, 0x53, 0x75, 0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65,
-	0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x08, 0x5a, 0x06, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x38, 0x0a, 0x08, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x22, 0x77, 0x0a, 0x08, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x52, 0x12, 0x28, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x41, 0x0a, 0x06, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a,
+	0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x52, 0x4f, 0x4f, 0x4d,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c,
+	0x4e, 0x4f, 0x54, 0x5f, 0x41, 0x5f, 0x4d, 0x45, 0x4d, 0x42, 0x45, 0x52, 0x10, 0x03, 0x22, 0x48,
+	0x0a, 0x0e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53,
+	0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x8b, 0x01, 0x0a, 0x0e, 0x44, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x12, 0x2e, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x44, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x49, 0x0a, 0x06, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a,
+	0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x4e, 0x4b, 0x4e,
+	0x4f, 0x57, 0x4e, 0x5f, 0x55, 0x53, 0x45, 0x52, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x51, 0x55,
+	0x45, 0x55, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x55, 0x45, 0x55, 0x45, 0x5f,
+	0x46, 0x55, 0x4c, 0x4c, 0x10, 0x04, 0x22, 0x37, 0x0a, 0x0b, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70,
+	0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x22,
+	0xb8, 0x01, 0x0a, 0x0c, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2c, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x14, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x7a,
+	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00,
+	0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x52,
+	0x4f, 0x4f, 0x4d, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e,
+	0x52, 0x4f, 0x4f, 0x4d, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x03,
+	0x12, 0x10, 0x0a, 0x0c, 0x4e, 0x4f, 0x54, 0x5f, 0x41, 0x5f, 0x4d, 0x45, 0x4d, 0x42, 0x45, 0x52,
+	0x10, 0x04, 0x12, 0x14, 0x0a, 0x10, 0x41, 0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x41, 0x5f,
+	0x4d, 0x45, 0x4d, 0x42, 0x45, 0x52, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x49, 0x4e, 0x56, 0x41,
+	0x4c, 0x49, 0x44, 0x5f, 0x4e, 0x41, 0x4d, 0x45, 0x10, 0x06, 0x22, 0x12, 0x0a, 0x10, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x6f, 0x6f, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x66,
+	0x0a, 0x08, 0x52, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74,
+	0x6f, 0x70, 0x69, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x6a, 0x6f, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x6a, 0x6f, 0x69, 0x6e, 0x65, 0x64, 0x22, 0x34, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x6f,
+	0x6f, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x72,
+	0x6f, 0x6f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x52, 0x6f, 0x6f,
+	0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x72, 0x6f, 0x6f, 0x6d, 0x73, 0x22, 0x41, 0x0a, 0x0a,
+	0x53, 0x75, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61,
+	0x63, 0x6b, 0x6c, 0x6f, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x62, 0x61, 0x63,
+	0x6b, 0x6c, 0x6f, 0x67, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x22,
+	0xff, 0x01, 0x0a, 0x0a, 0x53, 0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2a,
+	0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12,
+	0x2e, 0x53, 0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69,
+	0x65, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x63, 0x69, 0x70,
+	0x69, 0x65, 0x6e, 0x74, 0x22, 0x1b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06,
+	0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10,
+	0x01, 0x22, 0x4c, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x12, 0x18, 0x0a,
+	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x72,
+	0x6f, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x22,
+	0xc4, 0x01, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x12, 0x28, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x43,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x22, 0x5e, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f,
+	0x52, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f, 0x43,
+	0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x49, 0x4e, 0x56, 0x41,
+	0x4c, 0x49, 0x44, 0x5f, 0x41, 0x52, 0x47, 0x55, 0x4d, 0x45, 0x4e, 0x54, 0x53, 0x10, 0x03, 0x12,
+	0x15, 0x0a, 0x11, 0x50, 0x45, 0x52, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45,
+	0x4e, 0x49, 0x45, 0x44, 0x10, 0x04, 0x22, 0x89, 0x01, 0x0a, 0x0d, 0x41, 0x6e, 0x6e, 0x6f, 0x75,
+	0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x25, 0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52,
+	0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x05, 0x2e, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x04, 0x72, 0x6f,
+	0x6c, 0x65, 0x22, 0xa4, 0x01, 0x0a, 0x0d, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x52, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x52, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65,
+	0x6e, 0x74, 0x73, 0x22, 0x44, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a,
+	0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01,
+	0x12, 0x15, 0x0a, 0x11, 0x50, 0x45, 0x52, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44,
+	0x45, 0x4e, 0x49, 0x45, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x5f, 0x55, 0x53, 0x45, 0x52, 0x10, 0x03, 0x22, 0xd6, 0x01, 0x0a, 0x0f, 0x53, 0x75,
+	0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x2f, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
+	0x53, 0x75, 0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x08, 0x73, 0x65, 0x76, 0x65,
+	0x72, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x53, 0x65, 0x76,
+	0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x1b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52,
+	0x10, 0x01, 0x22, 0x53, 0x0a, 0x0b, 0x52, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x61, 0x77, 0x61, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x61, 0x77, 0x61,
+	0x79, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x22, 0xef, 0x01, 0x0a, 0x0d, 0x50, 0x72, 0x65, 0x73,
+	0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e,
+	0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x20, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x52, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x24, 0x0a, 0x06, 0x72, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x52, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x06, 0x72, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x4f, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x0c, 0x0a, 0x08, 0x53, 0x4e, 0x41, 0x50, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x00, 0x12, 0x09,
+	0x0a, 0x05, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x4f, 0x47,
+	0x4f, 0x55, 0x54, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x44, 0x49, 0x53, 0x43, 0x4f, 0x4e, 0x4e,
+	0x45, 0x43, 0x54, 0x10, 0x03, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x57, 0x41, 0x59, 0x10, 0x04, 0x12,
+	0x08, 0x0a, 0x04, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x05, 0x2a, 0x2f, 0x0a, 0x08, 0x53, 0x65, 0x76,
+	0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x00, 0x12,
+	0x0b, 0x0a, 0x07, 0x57, 0x41, 0x52, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08,
+	0x43, 0x52, 0x49, 0x54, 0x49, 0x43, 0x41, 0x4c, 0x10, 0x02, 0x2a, 0x2b, 0x0a, 0x04, 0x52, 0x6f,
+	0x6c, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x56, 0x45, 0x52, 0x59, 0x4f, 0x4e, 0x45, 0x10, 0x00,
+	0x12, 0x0a, 0x0a, 0x06, 0x41, 0x44, 0x4d, 0x49, 0x4e, 0x53, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05,
+	0x55, 0x53, 0x45, 0x52, 0x53, 0x10, 0x02, 0x32, 0xd9, 0x02, 0x0a, 0x0b, 0x43, 0x68, 0x61, 0x74,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x09, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x53, 0x1a, 0x09, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x22, 0x00, 0x12, 0x30,
+	0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x0b, 0x2e, 0x53, 0x75, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0b, 0x2e, 0x53, 0x75, 0x62, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x30, 0x01,
+	0x12, 0x37, 0x0a, 0x11, 0x53, 0x65, 0x6e, 0x64, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x0f, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x1a, 0x0f, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0a, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x52, 0x6f, 0x6f, 0x6d, 0x12, 0x0c, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x34, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x6f,
+	0x6f, 0x6d, 0x73, 0x12, 0x11, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x6f, 0x6f, 0x6d, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x6f, 0x6f,
+	0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x29, 0x0a, 0x08,
+	0x4a, 0x6f, 0x69, 0x6e, 0x52, 0x6f, 0x6f, 0x6d, 0x12, 0x0c, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x2a, 0x0a, 0x09, 0x4c, 0x65, 0x61, 0x76, 0x65,
+	0x52, 0x6f, 0x6f, 0x6d, 0x12, 0x0c, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x52, 0x6f, 0x6f, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x32, 0x37, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x12, 0x09, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x1a,
+	0x09, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x22, 0x00, 0x32, 0x85, 0x01, 0x0a,
+	0x13, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x35, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x41, 0x6e, 0x6e, 0x6f,
+	0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0b, 0x2e, 0x53, 0x75, 0x62, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x53, 0x75, 0x62, 0x41, 0x6e, 0x6e, 0x6f, 0x75,
+	0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x12, 0x37, 0x0a, 0x13, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x12, 0x0e, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x53, 0x1a, 0x0e, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x52, 0x22, 0x00, 0x32, 0x47, 0x0a, 0x0f, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x34, 0x0a, 0x11, 0x53, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x0b, 0x2e, 0x53,
+	0x75, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x50, 0x72, 0x65, 0x73,
+	0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x08, 0x5a,
+	0x06, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -654,39 +1836,80 @@ func file_proto_communication_proto_rawDescGZIP() []byte {
 	return file_proto_communication_proto_rawDescData
 }
 
-var file_proto_communication_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_proto_communication_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_communication_proto_enumTypes = make([]protoimpl.EnumInfo, 10)
+var file_proto_communication_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_proto_communication_proto_goTypes = []interface{}{
-	(MessageR_Status)(0),        // 0: MessageR.Status
-	(SubMessage_Status)(0),      // 1: SubMessage.Status
-	(CommandR_Status)(0),        // 2: CommandR.Status
-	(SubAnnouncement_Status)(0), // 3: SubAnnouncement.Status
-	(*MessageS)(nil),            // 4: MessageS
-	(*MessageR)(nil),            // 5: MessageR
-	(*SubRequest)(nil),          // 6: SubRequest
-	(*SubMessage)(nil),          // 7: SubMessage
-	(*CommandS)(nil),            // 8: CommandS
-	(*CommandR)(nil),            // 9: CommandR
-	(*SubAnnouncement)(nil),     // 10: SubAnnouncement
+	(Severity)(0),               // 0: Severity
+	(Role)(0),                   // 1: Role
+	(MessageR_Status)(0),        // 2: MessageR.Status
+	(DirectMessageR_Status)(0),  // 3: DirectMessageR.Status
+	(RoomResponse_Status)(0),    // 4: RoomResponse.Status
+	(SubMessage_Status)(0),      // 5: SubMessage.Status
+	(CommandR_Status)(0),        // 6: CommandR.Status
+	(AnnouncementR_Status)(0),   // 7: AnnouncementR.Status
+	(SubAnnouncement_Status)(0), // 8: SubAnnouncement.Status
+	(PresenceEvent_Type)(0),     // 9: PresenceEvent.Type
+	(*MessageS)(nil),            // 10: MessageS
+	(*MessageR)(nil),            // 11: MessageR
+	(*DirectMessageS)(nil),      // 12: DirectMessageS
+	(*DirectMessageR)(nil),      // 13: DirectMessageR
+	(*RoomRequest)(nil),         // 14: RoomRequest
+	(*RoomResponse)(nil),        // 15: RoomResponse
+	(*ListRoomsRequest)(nil),    // 16: ListRoomsRequest
+	(*RoomInfo)(nil),            // 17: RoomInfo
+	(*ListRoomsResponse)(nil),   // 18: ListRoomsResponse
+	(*SubRequest)(nil),          // 19: SubRequest
+	(*SubMessage)(nil),          // 20: SubMessage
+	(*CommandS)(nil),            // 21: CommandS
+	(*CommandR)(nil),            // 22: CommandR
+	(*AnnouncementS)(nil),       // 23: AnnouncementS
+	(*AnnouncementR)(nil),       // 24: AnnouncementR
+	(*SubAnnouncement)(nil),     // 25: SubAnnouncement
+	(*RosterEntry)(nil),         // 26: RosterEntry
+	(*PresenceEvent)(nil),       // 27: PresenceEvent
 }
 var file_proto_communication_proto_depIdxs = []int32{
-	0,  // 0: MessageR.status:type_name -> MessageR.Status
-	1,  // 1: SubMessage.status:type_name -> SubMessage.Status
-	2,  // 2: CommandR.status:type_name -> CommandR.Status
-	3,  // 3: SubAnnouncement.status:type_name -> SubAnnouncement.Status
-	4,  // 4: ChatService.SendMessage:input_type -> MessageS
-	6,  // 5: ChatService.SubscribeMessage:input_type -> SubRequest
-	8,  // 6: CommandService.SendCommand:input_type -> CommandS
-	6,  // 7: AnnouncementService.SendAnnouncement:input_type -> SubRequest
-	5,  // 8: ChatService.SendMessage:output_type -> MessageR
-	7,  // 9: ChatService.SubscribeMessage:output_type -> SubMessage
-	9,  // 10: CommandService.SendCommand:output_type -> CommandR
-	10, // 11: AnnouncementService.SendAnnouncement:output_type -> SubAnnouncement
-	8,  // [8:12] is the sub-list for method output_type
-	4,  // [4:8] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	2,  // 0: MessageR.status:type_name -> MessageR.Status
+	3,  // 1: DirectMessageR.status:type_name -> DirectMessageR.Status
+	4,  // 2: RoomResponse.status:type_name -> RoomResponse.Status
+	17, // 3: ListRoomsResponse.rooms:type_name -> RoomInfo
+	5,  // 4: SubMessage.status:type_name -> SubMessage.Status
+	6,  // 5: CommandR.status:type_name -> CommandR.Status
+	0,  // 6: AnnouncementS.severity:type_name -> Severity
+	1,  // 7: AnnouncementS.role:type_name -> Role
+	7,  // 8: AnnouncementR.status:type_name -> AnnouncementR.Status
+	8,  // 9: SubAnnouncement.status:type_name -> SubAnnouncement.Status
+	0,  // 10: SubAnnouncement.severity:type_name -> Severity
+	9,  // 11: PresenceEvent.type:type_name -> PresenceEvent.Type
+	26, // 12: PresenceEvent.user:type_name -> RosterEntry
+	26, // 13: PresenceEvent.roster:type_name -> RosterEntry
+	10, // 14: ChatService.SendMessage:input_type -> MessageS
+	19, // 15: ChatService.SubscribeMessage:input_type -> SubRequest
+	12, // 16: ChatService.SendDirectMessage:input_type -> DirectMessageS
+	14, // 17: ChatService.CreateRoom:input_type -> RoomRequest
+	16, // 18: ChatService.ListRooms:input_type -> ListRoomsRequest
+	14, // 19: ChatService.JoinRoom:input_type -> RoomRequest
+	14, // 20: ChatService.LeaveRoom:input_type -> RoomRequest
+	21, // 21: CommandService.SendCommand:input_type -> CommandS
+	19, // 22: AnnouncementService.SendAnnouncement:input_type -> SubRequest
+	23, // 23: AnnouncementService.PublishAnnouncement:input_type -> AnnouncementS
+	19, // 24: PresenceService.SubscribePresence:input_type -> SubRequest
+	11, // 25: ChatService.SendMessage:output_type -> MessageR
+	20, // 26: ChatService.SubscribeMessage:output_type -> SubMessage
+	13, // 27: ChatService.SendDirectMessage:output_type -> DirectMessageR
+	15, // 28: ChatService.CreateRoom:output_type -> RoomResponse
+	18, // 29: ChatService.ListRooms:output_type -> ListRoomsResponse
+	15, // 30: ChatService.JoinRoom:output_type -> RoomResponse
+	15, // 31: ChatService.LeaveRoom:output_type -> RoomResponse
+	22, // 32: CommandService.SendCommand:output_type -> CommandR
+	25, // 33: AnnouncementService.SendAnnouncement:output_type -> SubAnnouncement
+	24, // 34: AnnouncementService.PublishAnnouncement:output_type -> AnnouncementR
+	27, // 35: PresenceService.SubscribePresence:output_type -> PresenceEvent
+	25, // [25:36] is the sub-list for method output_type
+	14, // [14:25] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_proto_communication_proto_init() }
@@ -720,7 +1943,7 @@ func file_proto_communication_proto_init() {
 			}
 		}
 		file_proto_communication_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubRequest); i {
+			switch v := v.(*DirectMessageS); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -732,7 +1955,7 @@ func file_proto_communication_proto_init() {
 			}
 		}
 		file_proto_communication_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubMessage); i {
+			switch v := v.(*DirectMessageR); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -744,7 +1967,7 @@ func file_proto_communication_proto_init() {
 			}
 		}
 		file_proto_communication_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CommandS); i {
+			switch v := v.(*RoomRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -756,7 +1979,7 @@ func file_proto_communication_proto_init() {
 			}
 		}
 		file_proto_communication_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CommandR); i {
+			switch v := v.(*RoomResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -768,6 +1991,114 @@ func file_proto_communication_proto_init() {
 			}
 		}
 		file_proto_communication_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRoomsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RoomInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRoomsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommandS); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommandR); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnnouncementS); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnnouncementR); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubAnnouncement); i {
 			case 0:
 				return &v.state
@@ -779,16 +2110,40 @@ func file_proto_communication_proto_init() {
 				return nil
 			}
 		}
+		file_proto_communication_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RosterEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_communication_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PresenceEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_communication_proto_rawDesc,
-			NumEnums:      4,
-			NumMessages:   7,
+			NumEnums:      10,
+			NumMessages:   18,
 			NumExtensions: 0,
-			NumServices:   3,
+			NumServices:   4,
 		},
 		GoTypes:           file_proto_communication_proto_goTypes,
 		DependencyIndexes: file_proto_communication_proto_depIdxs,
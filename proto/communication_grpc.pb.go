@@ -24,6 +24,11 @@ const _ = grpc.SupportPackageIsVersion7
 type ChatServiceClient interface {
 	SendMessage(ctx context.Context, in *MessageS, opts ...grpc.CallOption) (*MessageR, error)
 	SubscribeMessage(ctx context.Context, in *SubRequest, opts ...grpc.CallOption) (ChatService_SubscribeMessageClient, error)
+	SendDirectMessage(ctx context.Context, in *DirectMessageS, opts ...grpc.CallOption) (*DirectMessageR, error)
+	CreateRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error)
+	ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*ListRoomsResponse, error)
+	JoinRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error)
+	LeaveRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error)
 }
 
 type chatServiceClient struct {
@@ -75,12 +80,62 @@ func (x *chatServiceSubscribeMessageClient) Recv() (*SubMessage, error) {
 	return m, nil
 }
 
+func (c *chatServiceClient) SendDirectMessage(ctx context.Context, in *DirectMessageS, opts ...grpc.CallOption) (*DirectMessageR, error) {
+	out := new(DirectMessageR)
+	err := c.cc.Invoke(ctx, "/ChatService/SendDirectMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) CreateRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error) {
+	out := new(RoomResponse)
+	err := c.cc.Invoke(ctx, "/ChatService/CreateRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*ListRoomsResponse, error) {
+	out := new(ListRoomsResponse)
+	err := c.cc.Invoke(ctx, "/ChatService/ListRooms", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) JoinRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error) {
+	out := new(RoomResponse)
+	err := c.cc.Invoke(ctx, "/ChatService/JoinRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) LeaveRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*RoomResponse, error) {
+	out := new(RoomResponse)
+	err := c.cc.Invoke(ctx, "/ChatService/LeaveRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChatServiceServer is the server API for ChatService service.
 // All implementations must embed UnimplementedChatServiceServer
 // for forward compatibility
 type ChatServiceServer interface {
 	SendMessage(context.Context, *MessageS) (*MessageR, error)
 	SubscribeMessage(*SubRequest, ChatService_SubscribeMessageServer) error
+	SendDirectMessage(context.Context, *DirectMessageS) (*DirectMessageR, error)
+	CreateRoom(context.Context, *RoomRequest) (*RoomResponse, error)
+	ListRooms(context.Context, *ListRoomsRequest) (*ListRoomsResponse, error)
+	JoinRoom(context.Context, *RoomRequest) (*RoomResponse, error)
+	LeaveRoom(context.Context, *RoomRequest) (*RoomResponse, error)
 	mustEmbedUnimplementedChatServiceServer()
 }
 
@@ -94,6 +149,21 @@ func (UnimplementedChatServiceServer) SendMessage(context.Context, *MessageS) (*
 func (UnimplementedChatServiceServer) SubscribeMessage(*SubRequest, ChatService_SubscribeMessageServer) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribeMessage not implemented")
 }
+func (UnimplementedChatServiceServer) SendDirectMessage(context.Context, *DirectMessageS) (*DirectMessageR, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendDirectMessage not implemented")
+}
+func (UnimplementedChatServiceServer) CreateRoom(context.Context, *RoomRequest) (*RoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRoom not implemented")
+}
+func (UnimplementedChatServiceServer) ListRooms(context.Context, *ListRoomsRequest) (*ListRoomsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRooms not implemented")
+}
+func (UnimplementedChatServiceServer) JoinRoom(context.Context, *RoomRequest) (*RoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinRoom not implemented")
+}
+func (UnimplementedChatServiceServer) LeaveRoom(context.Context, *RoomRequest) (*RoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveRoom not implemented")
+}
 func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
 
 // UnsafeChatServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -146,6 +216,96 @@ func (x *chatServiceSubscribeMessageServer) Send(m *SubMessage) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _ChatService_SendDirectMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DirectMessageS)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).SendDirectMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ChatService/SendDirectMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).SendDirectMessage(ctx, req.(*DirectMessageS))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_CreateRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).CreateRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ChatService/CreateRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).CreateRoom(ctx, req.(*RoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_ListRooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRoomsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).ListRooms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ChatService/ListRooms",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).ListRooms(ctx, req.(*ListRoomsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_JoinRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).JoinRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ChatService/JoinRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).JoinRoom(ctx, req.(*RoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_LeaveRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).LeaveRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ChatService/LeaveRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).LeaveRoom(ctx, req.(*RoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,6 +317,26 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendMessage",
 			Handler:    _ChatService_SendMessage_Handler,
 		},
+		{
+			MethodName: "SendDirectMessage",
+			Handler:    _ChatService_SendDirectMessage_Handler,
+		},
+		{
+			MethodName: "CreateRoom",
+			Handler:    _ChatService_CreateRoom_Handler,
+		},
+		{
+			MethodName: "ListRooms",
+			Handler:    _ChatService_ListRooms_Handler,
+		},
+		{
+			MethodName: "JoinRoom",
+			Handler:    _ChatService_JoinRoom_Handler,
+		},
+		{
+			MethodName: "LeaveRoom",
+			Handler:    _ChatService_LeaveRoom_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -259,6 +439,7 @@ var CommandService_ServiceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AnnouncementServiceClient interface {
 	SendAnnouncement(ctx context.Context, in *SubRequest, opts ...grpc.CallOption) (AnnouncementService_SendAnnouncementClient, error)
+	PublishAnnouncement(ctx context.Context, in *AnnouncementS, opts ...grpc.CallOption) (*AnnouncementR, error)
 }
 
 type announcementServiceClient struct {
@@ -301,11 +482,21 @@ func (x *announcementServiceSendAnnouncementClient) Recv() (*SubAnnouncement, er
 	return m, nil
 }
 
+func (c *announcementServiceClient) PublishAnnouncement(ctx context.Context, in *AnnouncementS, opts ...grpc.CallOption) (*AnnouncementR, error) {
+	out := new(AnnouncementR)
+	err := c.cc.Invoke(ctx, "/AnnouncementService/PublishAnnouncement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AnnouncementServiceServer is the server API for AnnouncementService service.
 // All implementations must embed UnimplementedAnnouncementServiceServer
 // for forward compatibility
 type AnnouncementServiceServer interface {
 	SendAnnouncement(*SubRequest, AnnouncementService_SendAnnouncementServer) error
+	PublishAnnouncement(context.Context, *AnnouncementS) (*AnnouncementR, error)
 	mustEmbedUnimplementedAnnouncementServiceServer()
 }
 
@@ -316,6 +507,9 @@ type UnimplementedAnnouncementServiceServer struct {
 func (UnimplementedAnnouncementServiceServer) SendAnnouncement(*SubRequest, AnnouncementService_SendAnnouncementServer) error {
 	return status.Errorf(codes.Unimplemented, "method SendAnnouncement not implemented")
 }
+func (UnimplementedAnnouncementServiceServer) PublishAnnouncement(context.Context, *AnnouncementS) (*AnnouncementR, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishAnnouncement not implemented")
+}
 func (UnimplementedAnnouncementServiceServer) mustEmbedUnimplementedAnnouncementServiceServer() {}
 
 // UnsafeAnnouncementServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -350,13 +544,36 @@ func (x *announcementServiceSendAnnouncementServer) Send(m *SubAnnouncement) err
 	return x.ServerStream.SendMsg(m)
 }
 
+func _AnnouncementService_PublishAnnouncement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnnouncementS)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnouncementServiceServer).PublishAnnouncement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/AnnouncementService/PublishAnnouncement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnouncementServiceServer).PublishAnnouncement(ctx, req.(*AnnouncementS))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AnnouncementService_ServiceDesc is the grpc.ServiceDesc for AnnouncementService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var AnnouncementService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "AnnouncementService",
 	HandlerType: (*AnnouncementServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PublishAnnouncement",
+			Handler:    _AnnouncementService_PublishAnnouncement_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "SendAnnouncement",
@@ -366,3 +583,116 @@ var AnnouncementService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "proto/communication.proto",
 }
+
+// PresenceServiceClient is the client API for PresenceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PresenceServiceClient interface {
+	SubscribePresence(ctx context.Context, in *SubRequest, opts ...grpc.CallOption) (PresenceService_SubscribePresenceClient, error)
+}
+
+type presenceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPresenceServiceClient(cc grpc.ClientConnInterface) PresenceServiceClient {
+	return &presenceServiceClient{cc}
+}
+
+func (c *presenceServiceClient) SubscribePresence(ctx context.Context, in *SubRequest, opts ...grpc.CallOption) (PresenceService_SubscribePresenceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PresenceService_ServiceDesc.Streams[0], "/PresenceService/SubscribePresence", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &presenceServiceSubscribePresenceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PresenceService_SubscribePresenceClient interface {
+	Recv() (*PresenceEvent, error)
+	grpc.ClientStream
+}
+
+type presenceServiceSubscribePresenceClient struct {
+	grpc.ClientStream
+}
+
+func (x *presenceServiceSubscribePresenceClient) Recv() (*PresenceEvent, error) {
+	m := new(PresenceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PresenceServiceServer is the server API for PresenceService service.
+// All implementations must embed UnimplementedPresenceServiceServer
+// for forward compatibility
+type PresenceServiceServer interface {
+	SubscribePresence(*SubRequest, PresenceService_SubscribePresenceServer) error
+	mustEmbedUnimplementedPresenceServiceServer()
+}
+
+// UnimplementedPresenceServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPresenceServiceServer struct {
+}
+
+func (UnimplementedPresenceServiceServer) SubscribePresence(*SubRequest, PresenceService_SubscribePresenceServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribePresence not implemented")
+}
+func (UnimplementedPresenceServiceServer) mustEmbedUnimplementedPresenceServiceServer() {}
+
+// UnsafePresenceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PresenceServiceServer will
+// result in compilation errors.
+type UnsafePresenceServiceServer interface {
+	mustEmbedUnimplementedPresenceServiceServer()
+}
+
+func RegisterPresenceServiceServer(s grpc.ServiceRegistrar, srv PresenceServiceServer) {
+	s.RegisterService(&PresenceService_ServiceDesc, srv)
+}
+
+func _PresenceService_SubscribePresence_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PresenceServiceServer).SubscribePresence(m, &presenceServiceSubscribePresenceServer{stream})
+}
+
+type PresenceService_SubscribePresenceServer interface {
+	Send(*PresenceEvent) error
+	grpc.ServerStream
+}
+
+type presenceServiceSubscribePresenceServer struct {
+	grpc.ServerStream
+}
+
+func (x *presenceServiceSubscribePresenceServer) Send(m *PresenceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// PresenceService_ServiceDesc is the grpc.ServiceDesc for PresenceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PresenceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "PresenceService",
+	HandlerType: (*PresenceServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribePresence",
+			Handler:       _PresenceService_SubscribePresence_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/communication.proto",
+}
@@ -0,0 +1,450 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.9
+// source: proto/hello.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HelloFragment_Status int32
+
+const (
+	HelloFragment_OK                  HelloFragment_Status = 0
+	HelloFragment_UNSUPPORTED_VERSION HelloFragment_Status = 1 // version is the one the server speaks, there is no chain
+	HelloFragment_COOKIE_REQUIRED     HelloFragment_Status = 2 // send the request again with the cookie
+)
+
+// Enum value maps for HelloFragment_Status.
+var (
+	HelloFragment_Status_name = map[int32]string{
+		0: "OK",
+		1: "UNSUPPORTED_VERSION",
+		2: "COOKIE_REQUIRED",
+	}
+	HelloFragment_Status_value = map[string]int32{
+		"OK":                  0,
+		"UNSUPPORTED_VERSION": 1,
+		"COOKIE_REQUIRED":     2,
+	}
+)
+
+func (x HelloFragment_Status) Enum() *HelloFragment_Status {
+	p := new(HelloFragment_Status)
+	*p = x
+	return p
+}
+
+func (x HelloFragment_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HelloFragment_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_hello_proto_enumTypes[0].Descriptor()
+}
+
+func (HelloFragment_Status) Type() protoreflect.EnumType {
+	return &file_proto_hello_proto_enumTypes[0]
+}
+
+func (x HelloFragment_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HelloFragment_Status.Descriptor instead.
+func (HelloFragment_Status) EnumDescriptor() ([]byte, []int) {
+	return file_proto_hello_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// HelloRequest asks for the server information and the ca chain
+type HelloRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version   uint32   `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`            // protocol version of the client
+	Nonce     []byte   `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`                 // random, echoed in the answer so stray datagrams are ignored
+	Fragments []uint32 `protobuf:"varint,3,rep,packed,name=fragments,proto3" json:"fragments,omitempty"` // indexes to send again, all of them when empty
+	Discover  bool     `protobuf:"varint,4,opt,name=discover,proto3" json:"discover,omitempty"`          // only the server information, answered with one fragment without chain
+	Cookie    []byte   `protobuf:"bytes,5,opt,name=cookie,proto3" json:"cookie,omitempty"`               // from a COOKIE_REQUIRED answer, required to get the chain
+	Padding   []byte   `protobuf:"bytes,6,opt,name=padding,proto3" json:"padding,omitempty"`             // zeros up to the minimum request size
+}
+
+func (x *HelloRequest) Reset() {
+	*x = HelloRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_hello_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HelloRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloRequest) ProtoMessage() {}
+
+func (x *HelloRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_hello_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloRequest.ProtoReflect.Descriptor instead.
+func (*HelloRequest) Descriptor() ([]byte, []int) {
+	return file_proto_hello_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HelloRequest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HelloRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *HelloRequest) GetFragments() []uint32 {
+	if x != nil {
+		return x.Fragments
+	}
+	return nil
+}
+
+func (x *HelloRequest) GetDiscover() bool {
+	if x != nil {
+		return x.Discover
+	}
+	return false
+}
+
+func (x *HelloRequest) GetCookie() []byte {
+	if x != nil {
+		return x.Cookie
+	}
+	return nil
+}
+
+func (x *HelloRequest) GetPadding() []byte {
+	if x != nil {
+		return x.Padding
+	}
+	return nil
+}
+
+// HelloFragment is one datagram of the answer, the ca chain is split in
+// sequenced fragments so it is not limited to the size of a datagram
+type HelloFragment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status      HelloFragment_Status `protobuf:"varint,1,opt,name=status,proto3,enum=HelloFragment_Status" json:"status,omitempty"`
+	Version     uint32               `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // protocol version of the server
+	Nonce       []byte               `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	ServerName  string               `protobuf:"bytes,4,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`     // name in the server certificate, to verify it, not in discovery answers
+	Port        uint32               `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`                                  // port of the grpc server
+	Index       uint32               `protobuf:"varint,6,opt,name=index,proto3" json:"index,omitempty"`                                // of this fragment, from 0
+	Count       uint32               `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`                                // of fragments in the answer
+	Data        []byte               `protobuf:"bytes,8,opt,name=data,proto3" json:"data,omitempty"`                                   // part of the PEM ca chain
+	Checksum    uint32               `protobuf:"varint,9,opt,name=checksum,proto3" json:"checksum,omitempty"`                          // CRC-32 (IEEE) of data
+	ChainSha256 []byte               `protobuf:"bytes,10,opt,name=chain_sha256,json=chainSha256,proto3" json:"chain_sha256,omitempty"` // SHA-256 of the whole ca chain, checked once it is reassembled
+	// only in the answer to a discovery request
+	Name             string `protobuf:"bytes,11,opt,name=name,proto3" json:"name,omitempty"`                                                  // shown to users picking a server
+	Clients          uint32 `protobuf:"varint,12,opt,name=clients,proto3" json:"clients,omitempty"`                                           // sessions open on the server
+	MaxClients       uint32 `protobuf:"varint,13,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`                   // 0 when there is no limit
+	PasswordRequired bool   `protobuf:"varint,14,opt,name=password_required,json=passwordRequired,proto3" json:"password_required,omitempty"` // to register
+	Cookie           []byte `protobuf:"bytes,15,opt,name=cookie,proto3" json:"cookie,omitempty"`                                              // only in a COOKIE_REQUIRED answer, valid for a minute or two
+}
+
+func (x *HelloFragment) Reset() {
+	*x = HelloFragment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_hello_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HelloFragment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloFragment) ProtoMessage() {}
+
+func (x *HelloFragment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_hello_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloFragment.ProtoReflect.Descriptor instead.
+func (*HelloFragment) Descriptor() ([]byte, []int) {
+	return file_proto_hello_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HelloFragment) GetStatus() HelloFragment_Status {
+	if x != nil {
+		return x.Status
+	}
+	return HelloFragment_OK
+}
+
+func (x *HelloFragment) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *HelloFragment) GetServerName() string {
+	if x != nil {
+		return x.ServerName
+	}
+	return ""
+}
+
+func (x *HelloFragment) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *HelloFragment) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetChainSha256() []byte {
+	if x != nil {
+		return x.ChainSha256
+	}
+	return nil
+}
+
+func (x *HelloFragment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HelloFragment) GetClients() uint32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetMaxClients() uint32 {
+	if x != nil {
+		return x.MaxClients
+	}
+	return 0
+}
+
+func (x *HelloFragment) GetPasswordRequired() bool {
+	if x != nil {
+		return x.PasswordRequired
+	}
+	return false
+}
+
+func (x *HelloFragment) GetCookie() []byte {
+	if x != nil {
+		return x.Cookie
+	}
+	return nil
+}
+
+var File_proto_hello_proto protoreflect.FileDescriptor
+
+var file_proto_hello_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xaa, 0x01, 0x0a, 0x0c, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14,
+	0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06,
+	0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e,
+	0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e, 0x67,
+	0x22, 0xf6, 0x03, 0x0a, 0x0d, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x46, 0x72, 0x61, 0x67, 0x6d, 0x65,
+	0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x15, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x46, 0x72, 0x61, 0x67, 0x6d, 0x65,
+	0x6e, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73,
+	0x75, 0x6d, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73,
+	0x75, 0x6d, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x73, 0x68, 0x61, 0x32,
+	0x35, 0x36, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x53,
+	0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x43, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
+	0x5f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x10, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x06, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x22, 0x3e, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x55,
+	0x4e, 0x53, 0x55, 0x50, 0x50, 0x4f, 0x52, 0x54, 0x45, 0x44, 0x5f, 0x56, 0x45, 0x52, 0x53, 0x49,
+	0x4f, 0x4e, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x4f, 0x4f, 0x4b, 0x49, 0x45, 0x5f, 0x52,
+	0x45, 0x51, 0x55, 0x49, 0x52, 0x45, 0x44, 0x10, 0x02, 0x42, 0x08, 0x5a, 0x06, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_hello_proto_rawDescOnce sync.Once
+	file_proto_hello_proto_rawDescData = file_proto_hello_proto_rawDesc
+)
+
+func file_proto_hello_proto_rawDescGZIP() []byte {
+	file_proto_hello_proto_rawDescOnce.Do(func() {
+		file_proto_hello_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_hello_proto_rawDescData)
+	})
+	return file_proto_hello_proto_rawDescData
+}
+
+var file_proto_hello_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_hello_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_hello_proto_goTypes = []interface{}{
+	(HelloFragment_Status)(0), // 0: HelloFragment.Status
+	(*HelloRequest)(nil),      // 1: HelloRequest
+	(*HelloFragment)(nil),     // 2: HelloFragment
+}
+var file_proto_hello_proto_depIdxs = []int32{
+	0, // 0: HelloFragment.status:type_name -> HelloFragment.Status
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_hello_proto_init() }
+func file_proto_hello_proto_init() {
+	if File_proto_hello_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_hello_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HelloRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_hello_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HelloFragment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_hello_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_hello_proto_goTypes,
+		DependencyIndexes: file_proto_hello_proto_depIdxs,
+		EnumInfos:         file_proto_hello_proto_enumTypes,
+		MessageInfos:      file_proto_hello_proto_msgTypes,
+	}.Build()
+	File_proto_hello_proto = out.File
+	file_proto_hello_proto_rawDesc = nil
+	file_proto_hello_proto_goTypes = nil
+	file_proto_hello_proto_depIdxs = nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/corrreia/chatroom-grpc/server/pki"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// renewBefore is how long before the server certificate expires the server
+// warns about it
+const renewBefore = 30 * 24 * time.Hour
+
+const certsUsage = `usage: server certs <command> [flags] [user]
+
+commands:
+  init          create the ca and the server certificate
+  renew         replace the server certificate, keeping the ca and its hosts unless -cert_hosts is given
+  renew <user>  replace the client certificate and key of a user in the clients directory
+  issue <user>  write a client certificate for a user in the clients directory
+  info          show the certificates of the server
+
+flags:
+`
+
+// certFlags are the flags describing the certificates of the server, shared
+// by the server and the certs command.
+type certFlags struct {
+	dir        *string
+	keyType    *string
+	hosts      *string
+	validity   *time.Duration
+	caValidity *time.Duration
+}
+
+func addCertFlags(fs *flag.FlagSet) *certFlags {
+	return &certFlags{
+		dir:        fs.String("cert_dir", "./certs", "directory of the ca and server certificates, missing ones are generated"),
+		keyType:    fs.String("key_type", "ecdsa", "type of the generated keys: rsa, ecdsa or ed25519"),
+		hosts:      fs.String("cert_hosts", "", "names and addresses in the server certificate separated by commas, clients verify the first name, defaults to the hostname and localhost"),
+		validity:   fs.Duration("cert_validity", 365*24*time.Hour, "validity of the generated server and client certificates"),
+		caValidity: fs.Duration("ca_validity", 10*365*24*time.Hour, "validity of a generated ca"),
+	}
+}
+
+// options returns the options to generate certificates with. Without
+// -cert_hosts the hosts are the defaults, or none unless defaultHosts.
+func (f *certFlags) options(defaultHosts bool) (pki.Options, error) {
+	opts := pki.Options{KeyType: *f.keyType, CertValidity: *f.validity, CAValidity: *f.caValidity}
+	if err := pki.CheckKeyType(opts.KeyType); err != nil {
+		return opts, err
+	}
+	if opts.CertValidity <= 0 || opts.CAValidity <= 0 {
+		return opts, fmt.Errorf("certificate validity must be positive")
+	}
+
+	for _, host := range strings.Split(*f.hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			opts.Hosts = append(opts.Hosts, host)
+		}
+	}
+	if len(opts.Hosts) == 0 && defaultHosts {
+		if hostname, err := os.Hostname(); err == nil && hostname != "" {
+			opts.Hosts = append(opts.Hosts, hostname)
+		}
+		opts.Hosts = append(opts.Hosts, "localhost", "127.0.0.1", "::1")
+	}
+
+	return opts, nil
+}
+
+func (f *certFlags) path(file string) string {
+	return filepath.Join(*f.dir, file)
+}
+
+// certsCommand runs the certs subcommand with its arguments and returns the
+// exit status.
+func certsCommand(args []string) int {
+	fs := flag.NewFlagSet("certs", flag.ContinueOnError)
+	certs := addCertFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), certsUsage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var err error
+	switch {
+	case command == "init" && fs.NArg() == 0:
+		err = initCerts(certs)
+	case command == "renew" && fs.NArg() == 0:
+		err = renewCerts(certs)
+	case command == "renew" && fs.NArg() == 1:
+		err = clientCert(certs, fs.Arg(0), true)
+	case command == "issue" && fs.NArg() == 1:
+		err = clientCert(certs, fs.Arg(0), false)
+	case command == "info" && fs.NArg() == 0:
+		err = certsInfo(certs)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func initCerts(certs *certFlags) error {
+	opts, err := certs.options(true)
+	if err != nil {
+		return err
+	}
+
+	ca, err := pki.CreateCA(*certs.dir, opts)
+	if err != nil {
+		return err
+	}
+	if err := pki.RenewServerCert(*certs.dir, ca, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created the ca and the server certificate for %v in %v\n", strings.Join(opts.Hosts, ", "), *certs.dir)
+	return nil
+}
+
+// renewCerts replaces the server certificate. The ca stays the same so the
+// clients that pinned it keep trusting the server.
+func renewCerts(certs *certFlags) error {
+	opts, err := certs.options(false)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Hosts) == 0 {
+		current, err := pki.ReadCert(certs.path(pki.ServerCertFile))
+		if err != nil {
+			return fmt.Errorf("%v, give the hosts with -cert_hosts", err)
+		}
+		opts.Hosts = pki.CertHosts(current)
+	}
+
+	ca, err := pki.LoadCA(certs.path(pki.CACertFile), certs.path(pki.CAKeyFile))
+	if err != nil {
+		return err
+	}
+	if err := pki.RenewServerCert(*certs.dir, ca, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("Renewed the server certificate for %v, restart the server to use it\n", strings.Join(opts.Hosts, ", "))
+	return nil
+}
+
+// clientCert writes a client certificate and key for a user, or replaces them
+// when renew.
+func clientCert(certs *certFlags, username string, renew bool) error {
+	ca, err := pki.LoadCA(certs.path(pki.CACertFile), certs.path(pki.CAKeyFile))
+	if err != nil {
+		return err
+	}
+
+	write := ca.WriteClientCert
+	if renew {
+		write = ca.RenewClientCert
+	}
+	certPath, keyPath, err := write(*certs.dir, username, *certs.validity)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %v and %v\n", certPath, keyPath)
+	return nil
+}
+
+func certsInfo(certs *certFlags) error {
+	for _, file := range []string{pki.CACertFile, pki.ServerCertFile} {
+		path := certs.path(file)
+		cert, err := pki.ReadCert(path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(path)
+		fmt.Printf("  subject      %v\n", cert.Subject.CommonName)
+		fmt.Printf("  issuer       %v\n", cert.Issuer.CommonName)
+		fmt.Printf("  key          %v\n", keyAlgorithm(cert))
+		if !cert.IsCA {
+			fmt.Printf("  hosts        %v\n", strings.Join(pki.CertHosts(cert), ", "))
+		}
+		fmt.Printf("  valid until  %v\n", cert.NotAfter.Format(time.RFC3339))
+		fmt.Printf("  fingerprint  %v\n", utils.Fingerprint(cert.Raw))
+
+		// clients pin the root of a chain, not the ca signing the certificates
+		if file == pki.CACertFile && !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			chain, err := utils.ParseCAChain(data)
+			if err != nil {
+				return fmt.Errorf("invalid ca chain in %v: %v", path, err)
+			}
+			fmt.Printf("  root         %v\n", chain.Root.Subject.CommonName)
+			fmt.Printf("  pinned       %v\n", chain.Fingerprint())
+		}
+	}
+
+	return nil
+}
+
+func keyAlgorithm(cert *x509.Certificate) string {
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		return "rsa"
+	case x509.ECDSA:
+		return "ecdsa"
+	case x509.Ed25519:
+		return "ed25519"
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+// ensureCerts generates the certificates missing in the certificates
+// directory and warns when the server certificate is about to expire.
+func ensureCerts(certs *certFlags) error {
+	opts, err := certs.options(true)
+	if err != nil {
+		return err
+	}
+
+	created, err := pki.Ensure(*certs.dir, opts)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("Generated certificates for %v in %v", strings.Join(opts.Hosts, ", "), *certs.dir)
+	}
+
+	cert, err := pki.ReadCert(certs.path(pki.ServerCertFile))
+	if err != nil {
+		return err
+	}
+	if time.Until(cert.NotAfter) < renewBefore {
+		log.Printf("The server certificate expires on %v, renew it with: server certs renew", cert.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -1,15 +1,465 @@
 package console
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/pki"
+	"github.com/corrreia/chatroom-grpc/server/services"
+	"github.com/corrreia/chatroom-grpc/server/types"
 )
 
-func StartConsole() {
-	fmt.Println("Console started")
-	//infiite cycle
+// consoleCommand is a command of the operator console.
+type consoleCommand struct {
+	usage string
+	help  string
+	args  int // minimum number of arguments
+	run   func(c *console, args []string) error
+}
+
+// console is a REPL on the server stdin that manages the server state.
+type console struct {
+	state    *types.ServerState
+	shutdown func()
+	out      io.Writer
+	started  time.Time
+}
+
+var consoleCommands map[string]consoleCommand
+
+func init() {
+	// filled in init because help refers to the map
+	consoleCommands = map[string]consoleCommand{
+		"help":        {usage: "help", help: "list the commands", run: (*console).help},
+		"users":       {usage: "users", help: "list the registered users", run: listUsers((*types.ServerState).GetUserList)},
+		"connected":   {usage: "connected", help: "list the connected users", run: listUsers((*types.ServerState).GetConnectedUserList)},
+		"banned":      {usage: "banned", help: "list the banned users", run: listUsers((*types.ServerState).GetBannedUserList)},
+		"admins":      {usage: "admins", help: "list the admins", run: listUsers((*types.ServerState).GetAdminUserList)},
+		"ban":         {usage: "ban <user>", help: "ban a user and disconnect it", args: 1, run: (*console).ban},
+		"unban":       {usage: "unban <user>", help: "allow a banned user to log in again", args: 1, run: (*console).unban},
+		"kick":        {usage: "kick <user>", help: "disconnect a user", args: 1, run: (*console).kick},
+		"sessions":    {usage: "sessions [user]", help: "list the open sessions of everyone or of a user", run: (*console).sessions},
+		"lockouts":    {usage: "lockouts", help: "list the accounts and addresses locked after failed logins", run: (*console).lockouts},
+		"unlock":      {usage: "unlock <user|ip>", help: "clear the failed logins of an account or address", args: 1, run: (*console).unlock},
+		"op":          {usage: "op <user>", help: "make a user an admin", args: 1, run: setAdmin(true)},
+		"deop":        {usage: "deop <user>", help: "remove the admin rights of a user", args: 1, run: setAdmin(false)},
+		"announce":    {usage: "announce <info|warning|critical> <message...>", help: "send an announcement to everyone", args: 2, run: (*console).announce},
+		"max_clients": {usage: "max_clients [n]", help: "show or change the maximum number of clients, 0 for no limit", run: (*console).maxClients},
+		"max_per_ip":  {usage: "max_per_ip [n]", help: "show or change the maximum number of clients from one address, 0 for no limit", run: (*console).maxPerIp},
+		"issue_cert":  {usage: "issue_cert <user> [days]", help: "sign a client certificate for a user, valid 365 days by default", args: 1, run: clientCert(false)},
+		"renew_cert":  {usage: "renew_cert <user> [days]", help: "replace the client certificate and key of a user, valid 365 days by default", args: 1, run: clientCert(true)},
+		"password":    {usage: "password [new|-]", help: "show if a server password is set, change it or remove it with -", run: (*console).password},
+		"slowmode":    {usage: "slowmode [seconds|off]", help: "show or change the time users wait between messages", run: (*console).slowMode},
+		"stats":       {usage: "stats", help: "show server statistics", run: (*console).stats},
+		"shutdown":    {usage: "shutdown", help: "stop the server", run: (*console).stop},
+	}
+}
+
+// StartConsole reads commands from stdin until it is closed. On a terminal it
+// offers line editing and history, takes over the log output when it goes to
+// stderr, and calls shutdown on ctrl+c or ctrl+d. It returns a function that
+// restores the terminal, to call before the process exits.
+func StartConsole(state *types.ServerState, shutdown func()) func() {
+	c := &console{
+		state:    state,
+		shutdown: shutdown,
+		out:      os.Stdout,
+		started:  time.Now(),
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		go c.readLines()
+		return func() {}
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		log.Printf("Could not start the console: %v", err)
+		return func() {}
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "> ")
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+	c.out = t
+
+	// logs are written above the prompt
+	if log.Writer() == os.Stderr {
+		log.SetOutput(t)
+	}
+
+	go c.readTerminal(t)
+	log.Println("Console started, type help for the commands")
+
+	return func() {
+		term.Restore(fd, oldState)
+	}
+}
+
+func (c *console) readTerminal(t *term.Terminal) {
 	for {
-		//read input
-		//parse input
-		//execute command
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			c.shutdown()
+			return
+		}
+		if err != nil {
+			log.Printf("Console error: %v", err)
+			return
+		}
+
+		c.execute(line)
 	}
-}
\ No newline at end of file
+}
+
+// readLines reads commands when stdin is not a terminal.
+func (c *console) readLines() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		c.execute(scanner.Text())
+	}
+}
+
+func (c *console) printf(format string, args ...interface{}) {
+	fmt.Fprintf(c.out, format+"\n", args...)
+}
+
+func (c *console) execute(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	cmd, ok := consoleCommands[strings.ToLower(fields[0])]
+	if !ok {
+		c.printf("Unknown command %v, type help for the commands", fields[0])
+		return
+	}
+
+	args := fields[1:]
+	if len(args) < cmd.args {
+		c.printf("Usage: %v", cmd.usage)
+		return
+	}
+
+	if err := cmd.run(c, args); err != nil {
+		c.printf("Error: %v", err)
+	}
+}
+
+func (c *console) help(args []string) error {
+	names := make([]string, 0, len(consoleCommands))
+	for name := range consoleCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c.printf("%-48v %v", consoleCommands[name].usage, consoleCommands[name].help)
+	}
+	return nil
+}
+
+// listUsers returns a command that prints a user list of the state.
+func listUsers(list func(s *types.ServerState) []*types.User) func(c *console, args []string) error {
+	return func(c *console, args []string) error {
+		users := list(c.state)
+		for _, user := range users {
+			var flags []string
+			if user.IsConnected() {
+				flags = append(flags, fmt.Sprintf("connected (%d sessions)", len(c.state.GetUserSessions(user))))
+			}
+			if user.IsAdmin() {
+				flags = append(flags, "admin")
+			}
+			if user.IsBanned() {
+				flags = append(flags, "banned")
+			}
+			c.printf("%-24v %v", user.GetUsername(), strings.Join(flags, ", "))
+		}
+
+		c.printf("%d users", len(users))
+		return nil
+	}
+}
+
+func (c *console) user(username string) (*types.User, error) {
+	user := c.state.GetUserByUsername(username)
+	if user == nil {
+		return nil, fmt.Errorf("user %v does not exist", username)
+	}
+	return user, nil
+}
+
+func (c *console) ban(args []string) error {
+	user, err := c.user(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.state.SetUserBanned(user, true); err != nil {
+		return err
+	}
+	if user.IsConnected() {
+		if err := services.KickUser(user); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("User %v was banned from the console", user.GetUsername())
+	return nil
+}
+
+func (c *console) unban(args []string) error {
+	user, err := c.user(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.state.SetUserBanned(user, false); err != nil {
+		return err
+	}
+
+	log.Printf("User %v was unbanned from the console", user.GetUsername())
+	return nil
+}
+
+func (c *console) kick(args []string) error {
+	user, err := c.user(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !user.IsConnected() {
+		return fmt.Errorf("user %v is not connected", user.GetUsername())
+	}
+
+	return services.KickUser(user)
+}
+
+func (c *console) sessions(args []string) error {
+	users := c.state.GetConnectedUserList()
+	if len(args) > 0 {
+		user, err := c.user(args[0])
+		if err != nil {
+			return err
+		}
+		users = []*types.User{user}
+	}
+
+	count := 0
+	for _, user := range users {
+		for _, session := range c.state.GetUserSessions(user) {
+			c.printf("%-24v %-32v %-16v %-24v active %v ago, expires %v", user.GetUsername(), session.GetId(), session.GetAddress(),
+				session.GetClient(), time.Since(session.GetLastActive()).Round(time.Second), session.GetExpires().Format(time.RFC3339))
+			count++
+		}
+	}
+
+	c.printf("%d sessions", count)
+	return nil
+}
+
+// clientCert returns the command writing a client certificate and key for a
+// user in the clients directory next to the ca, or replacing them when renew.
+// They authenticate the user without a password when client certificates are
+// enabled.
+func clientCert(renew bool) func(c *console, args []string) error {
+	return func(c *console, args []string) error {
+		user, err := c.user(args[0])
+		if err != nil {
+			return err
+		}
+
+		days := 365
+		if len(args) > 1 {
+			if days, err = strconv.Atoi(args[1]); err != nil || days <= 0 {
+				return fmt.Errorf("invalid number of days %v", args[1])
+			}
+		}
+
+		ca, err := pki.LoadCA(c.state.GetCaPath(), c.state.GetCaKeyPath())
+		if err != nil {
+			return err
+		}
+		write, action := ca.WriteClientCert, "issued"
+		if renew {
+			write, action = ca.RenewClientCert, "renewed"
+		}
+		certPath, keyPath, err := write(filepath.Dir(c.state.GetCaPath()), user.GetUsername(), time.Duration(days)*24*time.Hour)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Audit: client certificate for %v %v from the console, valid %d days", user.GetUsername(), action, days)
+		c.printf("Wrote %v and %v", certPath, keyPath)
+		return nil
+	}
+}
+
+func (c *console) lockouts(args []string) error {
+	lockouts := services.Lockouts()
+	for _, lockout := range lockouts {
+		c.printf("%-32v %d failures, until %v", lockout.Key, lockout.Failures, lockout.Until.Format(time.RFC3339))
+	}
+
+	c.printf("%d lockouts", len(lockouts))
+	return nil
+}
+
+func (c *console) unlock(args []string) error {
+	if !services.ClearLockout(args[0], "the console") {
+		return fmt.Errorf("%v has no failed logins", args[0])
+	}
+	return nil
+}
+
+// setAdmin returns the op and deop commands.
+func setAdmin(admin bool) func(c *console, args []string) error {
+	return func(c *console, args []string) error {
+		user, err := c.user(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := c.state.SetUserAdmin(user, admin); err != nil {
+			return err
+		}
+
+		if admin {
+			log.Printf("User %v was made an admin from the console", user.GetUsername())
+		} else {
+			log.Printf("User %v is no longer an admin, removed from the console", user.GetUsername())
+		}
+		return nil
+	}
+}
+
+func (c *console) announce(args []string) error {
+	severity, ok := pb.Severity_value[strings.ToUpper(args[0])]
+	if !ok {
+		return fmt.Errorf("unknown severity %v", args[0])
+	}
+
+	recipients, err := services.Announce("server", pb.Severity(severity), strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	c.printf("Announcement sent to %d streams", recipients)
+	return nil
+}
+
+func (c *console) maxClients(args []string) error {
+	if len(args) == 0 {
+		c.printf("Max clients: %d (%d sessions)", c.state.GetMaxClients(), c.state.GetCurrentClients())
+		return nil
+	}
+
+	max, err := strconv.Atoi(args[0])
+	if err != nil || max < 0 {
+		return fmt.Errorf("invalid number %v", args[0])
+	}
+
+	log.Printf("Max clients changed from the console to %d", max)
+	return c.state.SetMaxClients(max)
+}
+
+func (c *console) maxPerIp(args []string) error {
+	if len(args) == 0 {
+		c.printf("Max clients per address: %d", c.state.GetMaxPerAddress())
+		return nil
+	}
+
+	max, err := strconv.Atoi(args[0])
+	if err != nil || max < 0 {
+		return fmt.Errorf("invalid number %v", args[0])
+	}
+
+	log.Printf("Max clients per address changed from the console to %d", max)
+	return c.state.SetMaxPerAddress(max)
+}
+
+func (c *console) slowMode(args []string) error {
+	if len(args) == 0 {
+		if interval := c.state.GetSlowMode(); interval > 0 {
+			c.printf("Slow mode is on, one message every %v", interval)
+		} else {
+			c.printf("Slow mode is off")
+		}
+		return nil
+	}
+
+	interval, ok := services.ParseSlowMode(args[0])
+	if !ok {
+		return fmt.Errorf("invalid interval %v", args[0])
+	}
+
+	services.SetSlowMode("server", interval)
+	return nil
+}
+
+func (c *console) password(args []string) error {
+	if len(args) == 0 {
+		if c.state.GetServerPassword() == "" {
+			c.printf("No server password, anyone can register")
+		} else {
+			c.printf("A server password is set")
+		}
+		return nil
+	}
+
+	if args[0] == "-" {
+		log.Println("Server password removed from the console")
+		return c.state.SetServerPassword("")
+	}
+
+	log.Println("Server password changed from the console")
+	return c.state.SetServerPassword(args[0])
+}
+
+func (c *console) stats(args []string) error {
+	stats := services.GetStats()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	c.printf("Uptime:               %v", time.Since(c.started).Round(time.Second))
+	c.printf("Registered users:     %d", len(c.state.GetUserList()))
+	c.printf("Connected users:      %d", len(c.state.GetConnectedUserList()))
+	c.printf("Sessions:             %d / %d", c.state.GetCurrentClients(), c.state.GetMaxClients())
+	c.printf("Banned users:         %d", len(c.state.GetBannedUserList()))
+	c.printf("Admins:               %d", len(c.state.GetAdminUserList()))
+	c.printf("Messages:             %d", c.state.GetLastMessageId())
+	c.printf("Message streams:      %d", stats.MessageStreams)
+	c.printf("Announcement streams: %d", stats.AnnouncementStreams)
+	c.printf("Presence streams:     %d", stats.PresenceStreams)
+	c.printf("Hello answered:       %d (%d cookie challenges)", stats.Hello.Answered, stats.Hello.Challenged)
+	c.printf("Hello dropped:        %d malformed, %d unpadded, %d rate limited, %d oversized",
+		stats.Hello.Malformed, stats.Hello.Unpadded, stats.Hello.RateLimited, stats.Hello.Oversized)
+	c.printf("Goroutines:           %d", runtime.NumGoroutine())
+	c.printf("Memory:               %.1f MiB", float64(mem.Alloc)/1024/1024)
+	return nil
+}
+
+func (c *console) stop(args []string) error {
+	c.shutdown()
+	return nil
+}
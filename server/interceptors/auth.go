@@ -0,0 +1,137 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/corrreia/chatroom-grpc/server/types"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	sessionKey
+)
+
+// UserFromContext returns the user resolved by the auth interceptors.
+func UserFromContext(ctx context.Context) (*types.User, bool) {
+	user, ok := ctx.Value(userKey).(*types.User)
+	return user, ok
+}
+
+// SessionFromContext returns the session resolved by the auth interceptors.
+func SessionFromContext(ctx context.Context) (*types.Session, bool) {
+	session, ok := ctx.Value(sessionKey).(*types.Session)
+	return session, ok
+}
+
+// CertLogin returns the session of a user authenticated by a client
+// certificate, opening one if needed.
+type CertLogin func(ctx context.Context, user *types.User, cert *x509.Certificate) (*types.Session, error)
+
+// UnaryAuthInterceptor is a server interceptor that authenticates the caller
+// with the token in the request metadata and puts the user in the context.
+// Without a token, a client certificate verified by the tls layer identifies
+// the user by its common name when certLogin is not nil. The public methods
+// are called without authentication.
+func UnaryAuthInterceptor(state *types.ServerState, certLogin CertLogin, public ...string) grpc.UnaryServerInterceptor {
+	exempt := methodSet(public)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if exempt[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, state, certLogin)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the stream counterpart of UnaryAuthInterceptor.
+func StreamAuthInterceptor(state *types.ServerState, certLogin CertLogin, public ...string) grpc.StreamServerInterceptor {
+	exempt := methodSet(public)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), state, certLogin)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func methodSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		set[method] = true
+	}
+
+	return set
+}
+
+// authenticate resolves the token or the client certificate of the request
+// to a session and its user.
+func authenticate(ctx context.Context, state *types.ServerState, certLogin CertLogin) (context.Context, error) {
+	var session *types.Session
+	if token, ok := utils.TokenFromContext(ctx); ok {
+		session = state.GetSessionByToken(token)
+		if session == nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+	} else if cert := PeerCertificate(ctx); cert != nil && certLogin != nil {
+		var err error
+		if session, err = certSession(ctx, state, certLogin, cert); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	user := session.GetUser()
+	if user.IsBanned() {
+		return nil, status.Error(codes.PermissionDenied, "user is banned")
+	}
+
+	session.Touch()
+	ctx = context.WithValue(ctx, sessionKey, session)
+	return context.WithValue(ctx, userKey, user), nil
+}
+
+// certSession maps the common name of a client certificate to a user and
+// returns its session.
+func certSession(ctx context.Context, state *types.ServerState, certLogin CertLogin, cert *x509.Certificate) (*types.Session, error) {
+	user := state.GetUserByUsername(cert.Subject.CommonName)
+	if user == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "no user %v for the client certificate", cert.Subject.CommonName)
+	}
+	if user.IsBanned() {
+		return nil, status.Error(codes.PermissionDenied, "user is banned")
+	}
+
+	return certLogin(ctx, user, cert)
+}
+
+// authStream overrides the context of a server stream with the authenticated one.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,198 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// sweepInterval is how often buckets that refilled completely are forgotten
+const sweepInterval = time.Minute
+
+// Limit is the budget of a method for one caller: Burst requests at once,
+// refilled at Burst requests every Per.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// ParseLimits reads limits written as Method=burst/duration separated by
+// commas, like SendMessage=10/10s,Login=5/1m. Methods are the last part of
+// the full method name.
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		parts := strings.FieldsFunc(field, func(r rune) bool { return r == '=' || r == '/' })
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid rate limit %v, expected Method=burst/duration", field)
+		}
+		method, burst, per := parts[0], parts[1], parts[2]
+
+		n, err := strconv.Atoi(burst)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid burst in rate limit %v", field)
+		}
+		d, err := time.ParseDuration(per)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration in rate limit %v", field)
+		}
+
+		limits[method] = Limit{Burst: n, Per: d}
+	}
+
+	return limits, nil
+}
+
+// bucket is a token bucket of one caller for one method.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps the buckets of every method and caller, and the time of
+// the last message of each caller for the slow mode.
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    map[string]Limit
+	buckets   map[string]*bucket   // method and identity: bucket
+	lastSlow  map[string]time.Time // identity: last slowed request
+	interval  time.Duration        // last slow mode interval seen
+	lastSweep time.Time
+}
+
+// UnaryRateLimitInterceptor is a server interceptor that limits each caller,
+// the logged in user or the peer IP otherwise, to the budget of the method.
+// It must run after the auth interceptor. When the slow mode of the server is
+// on, callers that are not admins can only make the requests accepted by
+// slowed once every slow mode interval. Refused calls get ResourceExhausted
+// with the seconds to wait in the retry-after header.
+func UnaryRateLimitInterceptor(state *types.ServerState, limits map[string]Limit, slowed func(method string, req interface{}) bool) grpc.UnaryServerInterceptor {
+	r := &rateLimiter{
+		limits:    limits,
+		buckets:   make(map[string]*bucket),
+		lastSlow:  make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity := "ip:" + PeerIP(ctx)
+		user, loggedIn := UserFromContext(ctx)
+		if loggedIn {
+			identity = "user:" + user.GetId()
+		}
+
+		wait := r.take(path.Base(info.FullMethod), identity)
+		if wait == 0 && slowed(info.FullMethod, req) && !(loggedIn && user.IsAdmin()) {
+			wait = r.slow(identity, state.GetSlowMode())
+		}
+
+		if wait > 0 {
+			log.Printf("%v rate limited for %v", info.FullMethod, identity)
+			return nil, RetryAfter(ctx, wait)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RetryAfter returns a ResourceExhausted error for a request that can be
+// retried after wait, which is also sent in the retry-after header in seconds.
+func RetryAfter(ctx context.Context, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+	return status.Errorf(codes.ResourceExhausted, "too many requests, retry in %ds", seconds)
+}
+
+// take uses a token of the bucket of a caller for a method, it returns how
+// long to wait for one when the bucket is empty and 0 otherwise.
+func (r *rateLimiter) take(method string, identity string) time.Duration {
+	limit, ok := r.limits[method]
+	if !ok {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+
+	key := method + " " + identity
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		r.buckets[key] = b
+	}
+
+	// refill for the time since the last request
+	rate := float64(limit.Burst) / limit.Per.Seconds() // tokens per second
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return 0
+}
+
+// slow records a slowed request of a caller, it returns how long to wait when
+// the last one was less than interval ago and 0 otherwise.
+func (r *rateLimiter) slow(identity string, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interval = interval
+	now := time.Now()
+	if wait := r.lastSlow[identity].Add(interval).Sub(now); wait > 0 {
+		return wait
+	}
+
+	r.lastSlow[identity] = now
+	return 0
+}
+
+// sweep forgets the buckets that are full again and the slow mode entries
+// of callers that stopped a while ago. The caller must hold the lock.
+func (r *rateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+	r.lastSweep = now
+
+	for key, b := range r.buckets {
+		method := key[:strings.Index(key, " ")]
+		if now.Sub(b.last) >= r.limits[method].Per {
+			delete(r.buckets, key)
+		}
+	}
+
+	for identity, last := range r.lastSlow {
+		if now.Sub(last) >= r.interval {
+			delete(r.lastSlow, identity)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits(" Login=5/1m, SendMessage=10/10s,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Limit{
+		"Login":       {Burst: 5, Per: time.Minute},
+		"SendMessage": {Burst: 10, Per: 10 * time.Second},
+	}
+	if len(limits) != len(want) {
+		t.Fatalf("got %v limits, want %v", len(limits), len(want))
+	}
+	for method, limit := range want {
+		if limits[method] != limit {
+			t.Errorf("%v: got %+v, want %+v", method, limits[method], limit)
+		}
+	}
+}
+
+func TestParseLimitsEmpty(t *testing.T) {
+	limits, err := ParseLimits("")
+	if err != nil || len(limits) != 0 {
+		t.Fatalf("got %v, %v, want no limits", limits, err)
+	}
+}
+
+func TestParseLimitsInvalid(t *testing.T) {
+	for _, s := range []string{
+		"Login",
+		"Login=5",
+		"Login=5/1m/2",
+		"Login=x/1m",
+		"Login=0/1m",
+		"Login=-1/1m",
+		"Login=5/soon",
+		"Login=5/0s",
+		"Login=5/-1s",
+	} {
+		if _, err := ParseLimits(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func newTestLimiter(limits map[string]Limit) *rateLimiter {
+	return &rateLimiter{
+		limits:    limits,
+		buckets:   make(map[string]*bucket),
+		lastSlow:  make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func TestTakeBurst(t *testing.T) {
+	r := newTestLimiter(map[string]Limit{"Login": {Burst: 3, Per: 30 * time.Second}})
+
+	for i := 0; i < 3; i++ {
+		if wait := r.take("Login", "ip:a"); wait != 0 {
+			t.Fatalf("request %d: got wait %v, want none", i, wait)
+		}
+	}
+
+	// one token comes back every 10s
+	wait := r.take("Login", "ip:a")
+	if wait <= 9*time.Second || wait > 10*time.Second {
+		t.Fatalf("got wait %v, want about 10s", wait)
+	}
+}
+
+func TestTakeRefill(t *testing.T) {
+	r := newTestLimiter(map[string]Limit{"Login": {Burst: 2, Per: 20 * time.Second}})
+
+	r.take("Login", "ip:a")
+	r.take("Login", "ip:a")
+	if r.take("Login", "ip:a") == 0 {
+		t.Fatal("expected the bucket to be empty")
+	}
+
+	// pretend the last request was 10s ago, one token refilled
+	r.buckets["Login ip:a"].last = time.Now().Add(-10 * time.Second)
+	if wait := r.take("Login", "ip:a"); wait != 0 {
+		t.Fatalf("got wait %v after refilling, want none", wait)
+	}
+	if r.take("Login", "ip:a") == 0 {
+		t.Fatal("expected the refilled token to be used")
+	}
+
+	// the bucket never holds more than the burst
+	r.buckets["Login ip:a"].last = time.Now().Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		if wait := r.take("Login", "ip:a"); wait != 0 {
+			t.Fatalf("request %d: got wait %v, want none", i, wait)
+		}
+	}
+	if r.take("Login", "ip:a") == 0 {
+		t.Fatal("expected the bucket to be capped at the burst")
+	}
+}
+
+func TestTakeSeparatesCallersAndMethods(t *testing.T) {
+	r := newTestLimiter(map[string]Limit{
+		"Login":    {Burst: 1, Per: time.Minute},
+		"Register": {Burst: 1, Per: time.Minute},
+	})
+
+	if r.take("Login", "ip:a") != 0 || r.take("Login", "ip:b") != 0 || r.take("Register", "ip:a") != 0 {
+		t.Fatal("expected separate buckets per caller and method")
+	}
+	if r.take("Login", "ip:a") == 0 {
+		t.Fatal("expected the bucket of ip:a to be empty")
+	}
+}
+
+func TestTakeUnlimited(t *testing.T) {
+	r := newTestLimiter(map[string]Limit{})
+
+	for i := 0; i < 100; i++ {
+		if wait := r.take("SendMessage", "user:a"); wait != 0 {
+			t.Fatalf("got wait %v for a method without limit", wait)
+		}
+	}
+}
+
+func TestSlow(t *testing.T) {
+	r := newTestLimiter(nil)
+
+	if wait := r.slow("user:a", 0); wait != 0 {
+		t.Fatalf("got wait %v with the slow mode off", wait)
+	}
+
+	if wait := r.slow("user:a", 5*time.Second); wait != 0 {
+		t.Fatalf("got wait %v for the first message", wait)
+	}
+	if wait := r.slow("user:a", 5*time.Second); wait <= 4*time.Second || wait > 5*time.Second {
+		t.Fatalf("got wait %v, want about 5s", wait)
+	}
+	if wait := r.slow("user:b", 5*time.Second); wait != 0 {
+		t.Fatalf("got wait %v for another user", wait)
+	}
+
+	r.lastSlow["user:a"] = time.Now().Add(-6 * time.Second)
+	if wait := r.slow("user:a", 5*time.Second); wait != 0 {
+		t.Fatalf("got wait %v after the interval", wait)
+	}
+}
+
+// headerStream records the headers set by a handler.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/AuthService/Login" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		wait    time.Duration
+		seconds string
+	}{
+		{wait: 1500 * time.Millisecond, seconds: "2"},
+		{wait: 3 * time.Second, seconds: "3"},
+		{wait: time.Millisecond, seconds: "1"},
+	} {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+		err := RetryAfter(ctx, tc.wait)
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("%v: got code %v, want ResourceExhausted", tc.wait, status.Code(err))
+		}
+		if got := stream.header.Get("retry-after"); len(got) != 1 || got[0] != tc.seconds {
+			t.Errorf("%v: got retry-after %v, want %v", tc.wait, got, tc.seconds)
+		}
+	}
+}
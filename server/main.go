@@ -1,15 +1,21 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/corrreia/chatroom-grpc/server/console"
 	"github.com/corrreia/chatroom-grpc/server/interceptors"
+	"github.com/corrreia/chatroom-grpc/server/pki"
 	"github.com/corrreia/chatroom-grpc/server/services"
+	"github.com/corrreia/chatroom-grpc/server/storage"
 	"github.com/corrreia/chatroom-grpc/server/types"
 	"github.com/corrreia/chatroom-grpc/utils"
 
@@ -17,28 +23,39 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
+// shutdownTimeout is the time given to running requests when the server stops
+const shutdownTimeout = 10 * time.Second
 
+// maxNameLength keeps the discovery answers smaller than the requests
+const maxNameLength = 64
 
 func main() {
+	// certificates are managed by a subcommand with its own flags
+	if len(os.Args) > 1 && os.Args[1] == "certs" {
+		os.Exit(certsCommand(os.Args[2:]))
+	}
+
 	// parse flags
 	port := flag.Int("port", 8421, "port to listen on")
+	name := flag.String("name", "", "name shown to clients discovering the server, defaults to the hostname")
 	password := flag.String("password", "", "password to connect")
-	maxClients := flag.Int("max_clients", 10, "maximum number of clients")
+	maxClients := flag.Int("max_clients", 10, "maximum number of connected users, admins can always connect, 0 for no limit")
+	maxPerIp := flag.Int("max_per_ip", 3, "maximum number of connected users from one address, 0 for no limit")
 	logFile := flag.String("log_file", "", "log file")
+	startConsole := flag.Bool("console", true, "read operator commands from stdin")
+	awayAfter := flag.Duration("away_after", 5*time.Minute, "idle time before a user is shown as away, 0 to disable")
+	rateLimits := flag.String("rate_limits", "Login=5/1m,Register=3/1m,SendMessage=10/10s,SendDirectMessage=10/10s,SendCommand=10/10s",
+		"requests allowed per user or address, as Method=burst/duration separated by commas")
+	slowMode := flag.Duration("slow_mode", 0, "time users wait between messages, admins are not limited, 0 for off")
+	lockoutAfter := flag.Int("lockout_after", 5, "failed logins before an account is locked, 0 to never lock")
+	lockoutTime := flag.Duration("lockout_time", 15*time.Minute, "how long an account stays locked")
+	sessionTTL := flag.Duration("session_ttl", 24*time.Hour, "how long a login stays valid without being refreshed")
+	clientCerts := flag.String("client_certs", "off", "client certificates signed by the ca: off, optional to accept them besides tokens, or require")
+	dataDir := flag.String("data_dir", "", "directory to persist users and chat history in, they are kept in memory if empty")
+	historyLimit := flag.Int("history_limit", 0, "messages kept in the chat history of data_dir, older ones are deleted, 0 keeps all of them")
+	certs := addCertFlags(flag.CommandLine)
 	flag.Parse()
 
-	certPath := "./certs"
-
-	// create server state
-	state := types.NewServerState()
-
-	state.SetServerPassword(*password)
-	state.SetMaxClients(*maxClients)
-	state.SetPort(*port)
-	state.SetCaPath(filepath.Join(certPath, "ca_cert.pem"))
-	state.SetCertPath(filepath.Join(certPath, "server_cert.pem"))
-	state.SetKeyPath(filepath.Join(certPath, "server_key.pem"))
-
 	// set up logging
 	if *logFile != "" {
 		f, err := os.OpenFile(*logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
@@ -49,6 +66,43 @@ func main() {
 		log.SetOutput(f)
 	}
 
+	// create server state
+	var store types.Storage = storage.NewMemoryStorage()
+	if *dataDir != "" {
+		fileStore, err := storage.NewFileStorage(*dataDir, *historyLimit)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store = fileStore
+	}
+	defer store.Close()
+
+	state := types.NewServerState(store)
+	if err := state.Load(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Loaded %d users", len(state.GetUserList()))
+
+	if *name == "" {
+		*name, _ = os.Hostname()
+	}
+	if len(*name) > maxNameLength {
+		log.Fatalf("name is longer than %d bytes", maxNameLength)
+	}
+	state.SetName(*name)
+	state.SetServerPassword(*password)
+	state.SetMaxClients(*maxClients)
+	state.SetMaxPerAddress(*maxPerIp)
+	state.SetAwayTimeout(*awayAfter)
+	state.SetSlowMode(*slowMode)
+	state.SetLockout(*lockoutAfter, *lockoutTime)
+	state.SetSessionTTL(*sessionTTL)
+	state.SetPort(*port)
+	state.SetCaPath(certs.path(pki.CACertFile))
+	state.SetCaKeyPath(certs.path(pki.CAKeyFile))
+	state.SetCertPath(certs.path(pki.ServerCertFile))
+	state.SetKeyPath(certs.path(pki.ServerKeyFile))
+
 	log.Println("Random test token:", utils.GenerateToken())
 
 	// open sockets
@@ -57,49 +111,125 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// generate the certificates on the first start
+	if err := ensureCerts(certs); err != nil {
+		log.Fatal(err)
+	}
+
 	// Create tls based credential.
-	creds, err := credentials.NewServerTLSFromFile(state.GetCertPath(), state.GetKeyPath())
+	creds, certLogin, err := serverCredentials(state, *clientCerts)
 	if err != nil {
 		log.Fatal(err)
 	}
-	
+
 	log.Println("Server credentials loaded")
 
-	//create grpc servers
-	authS := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(interceptors.UnaryLogInterceptor))
-	mainS := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(interceptors.UnaryLogInterceptor)) //!TODO: add auth interceptor
+	limits, err := interceptors.ParseLimits(*rateLimits)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	services.StartAuthServer(authS, state) // auth service to authenticate clients and get token
-	services.StartCommunicationServer(mainS, state)  // communication service to send messages and commands
-	
-	errCh := make(chan error)
+	//create grpc server, login and register are the only methods that do not need a token
+	s := grpc.NewServer(grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(interceptors.UnaryLogInterceptor, interceptors.UnaryAuthInterceptor(state, certLogin, services.PublicMethods...),
+			interceptors.UnaryRateLimitInterceptor(state, limits, services.SlowModeRequest)),
+		grpc.ChainStreamInterceptor(interceptors.StreamLogInterceptor, interceptors.StreamAuthInterceptor(state, certLogin, services.PublicMethods...)))
 
-	go func() {
-        err = services.StartHelloServer(udpSock, state.GetCaPath())
-		if err!= nil {
-            errCh <- err
-        }
+	services.StartAuthServer(s, state) // auth service to authenticate clients and get token
+	services.StartCommunicationServer(s, state)  // communication service to send messages and commands
+
+	errCh := make(chan error, 2)
+
+	go func() { //start hello server in a goroutine and send errors to channel
+		if err := services.StartHelloServer(udpSock, state); err != nil {
+			errCh <- err
+		}
 	}()
 
-	go func () {  //start hello server in a goroutine and send errors to channel
-		err = authS.Serve(tcpSock)
-		if err!= nil {
-            errCh <- err
-        }
+	go func() { //start grpc server in a goroutine and send errors to channel
+		if err := s.Serve(tcpSock); err != nil {
+			errCh <- err
+		}
 	}()
 
-	go func () { //start main server in a goroutine and send errors to channel
-		err = mainS.Serve(tcpSock)
-		if err!= nil {
-            errCh <- err
-        }		
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	restoreConsole := func() {}
+	if *startConsole {
+		restoreConsole = console.StartConsole(state, func() {
+			select { // stop as if the server got a signal
+			case sigCh <- syscall.SIGTERM:
+			default:
+			}
+		})
+	}
+
+	select { //wait for errors or a signal to stop
+	case err := <-errCh:
+		restoreConsole()
+		log.Fatal(err)
+	case sig := <-sigCh:
+		log.Printf("Received %v, shutting down", sig)
+	}
+
+	udpSock.Close()
+	shutdown(s)
+	restoreConsole()
+}
+
+// shutdown closes the open streams and stops the server, waiting at most
+// shutdownTimeout for the running requests to finish.
+func shutdown(s *grpc.Server) {
+	services.CloseStreams()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
 	}()
 
-	for { //wait for errors and exit if there is one
-		if err := <-errCh; err != nil {
-			log.Fatal(err)
-		}
+	select {
+	case <-stopped:
+		log.Println("Server stopped")
+	case <-time.After(shutdownTimeout):
+		log.Println("Server did not stop in time, closing connections")
+		s.Stop()
+	}
+}
+
+// serverCredentials returns the tls credentials of the server. Unless mode is
+// off, clients can authenticate with a certificate signed by the ca of the
+// server and the returned CertLogin maps it to a user.
+func serverCredentials(state *types.ServerState, mode string) (credentials.TransportCredentials, interceptors.CertLogin, error) {
+	cert, err := pki.ServerCertificate(state.GetCertPath(), state.GetKeyPath(), state.GetCaPath())
+	if err != nil {
+		return nil, nil, err
 	}
+
+	var clientAuth tls.ClientAuthType
+	switch mode {
+	case "off":
+		return credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}}), nil, nil
+	case "optional":
+		clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, nil, fmt.Errorf("invalid client_certs %v, expected off, optional or require", mode)
+	}
+
+	pool, err := pki.CertPool(state.GetCaPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("Client certificates: %v", mode)
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   clientAuth,
+	}), services.CertLogin, nil
 }
 
 func openSockets(port int) (net.Listener, net.PacketConn, error) {
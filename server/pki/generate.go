@@ -0,0 +1,421 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Names of the files in the certificates directory of the server.
+const (
+	CACertFile     = "ca_cert.pem"
+	CAKeyFile      = "ca_key.pem"
+	ServerCertFile = "server_cert.pem"
+	ServerKeyFile  = "server_key.pem"
+)
+
+// ClientsDir is the directory next to the ca where client certificates are
+// written
+const ClientsDir = "clients"
+
+// rsaBits is the size of the generated rsa keys
+const rsaBits = 3072
+
+// clientNamePattern is the usernames client certificates can be written for,
+// they are part of the file names.
+var clientNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Options describe the certificates generated for a server.
+type Options struct {
+	KeyType      string        // rsa, ecdsa or ed25519
+	Hosts        []string      // names and addresses of the server, clients verify the first name
+	CAValidity   time.Duration // validity of a new ca
+	CertValidity time.Duration // validity of the server and client certificates
+}
+
+// GenerateKey creates a private key of the given type: rsa, ecdsa or ed25519.
+func GenerateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, rsaBits)
+	case "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, CheckKeyType(keyType)
+	}
+}
+
+// CheckKeyType returns an error unless GenerateKey accepts the key type.
+func CheckKeyType(keyType string) error {
+	switch keyType {
+	case "rsa", "ecdsa", "ed25519":
+		return nil
+	default:
+		return fmt.Errorf("invalid key type %v, expected rsa, ecdsa or ed25519", keyType)
+	}
+}
+
+// NewCA creates a self signed certificate authority, it only signs end
+// entity certificates.
+func NewCA(key crypto.Signer, name string, validity time.Duration) (*CA, error) {
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{Cert: cert, Key: key}, nil
+}
+
+// IssueServerCert signs a PEM encoded server certificate for a key, valid
+// for the given names and addresses. The first one is the common name.
+func (ca *CA) IssueServerCert(key crypto.Signer, hosts []string, validity time.Duration) ([]byte, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("a server certificate needs at least one host")
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// WriteClientCert issues a client certificate for a username and writes it
+// with its key to <username>_cert.pem and <username>_key.pem in the clients
+// directory of dir. Existing files are never replaced, RenewClientCert does.
+// It returns the paths of both files.
+func (ca *CA) WriteClientCert(dir string, username string, validity time.Duration) (string, string, error) {
+	certPem, keyPem, certPath, keyPath, err := ca.newClientFiles(dir, username, validity)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := writeNew(keyPath, keyPem, 0600); err != nil {
+		return "", "", err
+	}
+	if err := writeNew(certPath, certPem, 0644); err != nil {
+		os.Remove(keyPath)
+		return "", "", err
+	}
+
+	return certPath, keyPath, nil
+}
+
+// RenewClientCert replaces the client certificate and key of a username
+// written by WriteClientCert with new ones. Each file is written to a
+// temporary file first and renamed over the old one, the key first, so an
+// error leaves the old files in place. It returns the paths of both files.
+func (ca *CA) RenewClientCert(dir string, username string, validity time.Duration) (string, string, error) {
+	certPem, keyPem, certPath, keyPath, err := ca.newClientFiles(dir, username, validity)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, path := range []string{certPath, keyPath} {
+		if found, err := exists(path); err != nil {
+			return "", "", err
+		} else if !found {
+			return "", "", fmt.Errorf("%v does not exist, issue a certificate for %v first", path, username)
+		}
+	}
+
+	keyTmp, certTmp := keyPath+".tmp", certPath+".tmp"
+	if err := writeTemp(keyTmp, keyPem, 0600); err != nil {
+		return "", "", err
+	}
+	if err := writeTemp(certTmp, certPem, 0644); err != nil {
+		os.Remove(keyTmp)
+		return "", "", err
+	}
+	if err := os.Rename(keyTmp, keyPath); err != nil {
+		os.Remove(keyTmp)
+		os.Remove(certTmp)
+		return "", "", err
+	}
+	if err := os.Rename(certTmp, certPath); err != nil {
+		os.Remove(certTmp)
+		return "", "", fmt.Errorf("%v was renewed but not %v: %v", keyPath, certPath, err)
+	}
+
+	return certPath, keyPath, nil
+}
+
+// newClientFiles issues a client certificate for a username and returns it
+// with its key, and the paths they are written to, creating the clients
+// directory of dir.
+func (ca *CA) newClientFiles(dir string, username string, validity time.Duration) ([]byte, []byte, string, string, error) {
+	if !clientNamePattern.MatchString(username) {
+		return nil, nil, "", "", fmt.Errorf("cannot write a certificate for username %q", username)
+	}
+
+	certPem, keyPem, err := ca.IssueClientCert(username, validity)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	dir = filepath.Join(dir, ClientsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, "", "", err
+	}
+
+	certPath := filepath.Join(dir, username+"_cert.pem")
+	keyPath := filepath.Join(dir, username+"_key.pem")
+	return certPem, keyPem, certPath, keyPath, nil
+}
+
+// writeNew writes a file that must not exist yet.
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if os.IsExist(err) {
+		return fmt.Errorf("%v already exists, renew it to replace it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeAll(f, path, data)
+}
+
+// writeTemp writes a temporary file, replacing one left by an earlier
+// attempt, to rename it over the file it replaces.
+func writeTemp(path string, data []byte, perm os.FileMode) error {
+	os.Remove(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	return writeAll(f, path, data)
+}
+
+// writeAll writes data to a new file, syncs and closes it, and removes it on
+// error.
+func writeAll(f *os.File, path string, data []byte) error {
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeKey returns a private key as a PEM encoded PKCS #8 block.
+func EncodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ReadCert reads the first certificate of a PEM file.
+func ReadCert(path string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found in %v", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// CertHosts returns the names and addresses a certificate is valid for, in
+// the order IssueServerCert takes them. Certificates without any give their
+// common name.
+func CertHosts(cert *x509.Certificate) []string {
+	hosts := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		hosts = append(hosts, ip.String())
+	}
+	if len(hosts) == 0 && cert.Subject.CommonName != "" {
+		hosts = append(hosts, cert.Subject.CommonName)
+	}
+	return hosts
+}
+
+// Ensure creates what is missing in a certificates directory: the ca, then
+// the server certificate signed by it. It reports whether anything was
+// created. An existing ca is never replaced, clients pin it.
+func Ensure(dir string, opts Options) (bool, error) {
+	caCertPath := filepath.Join(dir, CACertFile)
+	caKeyPath := filepath.Join(dir, CAKeyFile)
+
+	caExists, err := exists(caCertPath)
+	if err != nil {
+		return false, err
+	}
+	serverExists, err := exists(filepath.Join(dir, ServerCertFile))
+	if err != nil {
+		return false, err
+	}
+	if caExists && serverExists {
+		return false, nil
+	}
+
+	var ca *CA
+	if caExists {
+		if ca, err = LoadCA(caCertPath, caKeyPath); err != nil {
+			return false, err
+		}
+	} else {
+		if ca, err = CreateCA(dir, opts); err != nil {
+			return false, err
+		}
+	}
+
+	return true, RenewServerCert(dir, ca, opts)
+}
+
+// CreateCA generates the ca of a server and writes it to dir. It fails
+// rather than replace an existing ca key.
+func CreateCA(dir string, opts Options) (*CA, error) {
+	keyPath := filepath.Join(dir, CAKeyFile)
+	if found, err := exists(keyPath); err != nil {
+		return nil, err
+	} else if found {
+		return nil, fmt.Errorf("%v already exists", keyPath)
+	}
+
+	key, err := GenerateKey(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "chatroom ca"
+	if len(opts.Hosts) > 0 {
+		name = opts.Hosts[0] + " ca"
+	}
+	ca, err := NewCA(key, name, opts.CAValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPem, err := EncodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw})
+	if err := writeFiles(dir, keyPath, keyPem, filepath.Join(dir, CACertFile), certPem); err != nil {
+		return nil, err
+	}
+
+	return ca, nil
+}
+
+// RenewServerCert generates a new server key and certificate signed by ca
+// and writes them to dir, replacing the previous ones.
+func RenewServerCert(dir string, ca *CA, opts Options) error {
+	key, err := GenerateKey(opts.KeyType)
+	if err != nil {
+		return err
+	}
+
+	certPem, err := ca.IssueServerCert(key, opts.Hosts, opts.CertValidity)
+	if err != nil {
+		return err
+	}
+	keyPem, err := EncodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	return writeFiles(dir, filepath.Join(dir, ServerKeyFile), keyPem, filepath.Join(dir, ServerCertFile), certPem)
+}
+
+// writeFiles creates dir if needed and writes a private key, only readable
+// by the owner, then its certificate.
+func writeFiles(dir string, keyPath string, keyPem []byte, certPath string, certPem []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(keyPath, keyPem, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(certPath, certPem, 0644)
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// newSerial returns a random 128 bit serial number.
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
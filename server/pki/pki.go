@@ -0,0 +1,132 @@
+// Package pki loads the certificate authority of the server and issues the
+// certificates it signs.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// CA is a certificate authority able to sign certificates.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// LoadCA reads a PEM certificate and private key of a certificate authority.
+func LoadCA(certPath string, keyPath string) (*CA, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("%v is not a ca certificate", certPath)
+	}
+
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported ca key")
+	}
+
+	return &CA{Cert: cert, Key: key}, nil
+}
+
+// CertPool returns a pool trusting the certificate of a PEM file.
+func CertPool(certPath string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %v", certPath)
+	}
+	return pool, nil
+}
+
+// ServerCertificate loads the server certificate and key, and appends the
+// intermediate cas of the ca chain at caPath that the certificate file does
+// not hold already. Clients only trust the root and need them to verify it.
+func ServerCertificate(certPath string, keyPath string, caPath string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return cert, err
+	}
+
+	data, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return cert, err
+	}
+	chain, err := utils.ParseCAChain(data)
+	if err != nil {
+		return cert, fmt.Errorf("invalid ca chain in %v: %v", caPath, err)
+	}
+
+	sent := make(map[string]bool)
+	for _, der := range cert.Certificate {
+		sent[string(der)] = true
+	}
+	for _, ca := range chain.Certs {
+		if ca != chain.Root && !sent[string(ca.Raw)] {
+			cert.Certificate = append(cert.Certificate, ca.Raw)
+		}
+	}
+
+	return cert, nil
+}
+
+// IssueClientCert creates a key and a client certificate for a username,
+// which is the common name of the subject. It returns both PEM encoded.
+func (ca *CA) IssueClientCert(username string, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: username},
+		NotBefore:    now.Add(-time.Minute), // tolerate clocks slightly behind
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPem, keyPem, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
+)
+
+var announcementHub = newHub()
+
+func (s *communicationServer) SendAnnouncement(req *pb.SubRequest, stream pb.AnnouncementService_SendAnnouncementServer) error {
+	user, ok := interceptors.UserFromContext(stream.Context())
+	if !ok {
+		return status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	// the stream is closed when the session logs out
+	session, _ := interceptors.SessionFromContext(stream.Context())
+	ctx, done := sessionStreams.register(stream.Context(), session.GetId())
+	defer done()
+
+	sub := announcementHub.subscribe(user)
+	defer announcementHub.unsubscribe(sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-sub.ch:
+			if err := stream.Send(item.(*pb.SubAnnouncement)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *communicationServer) PublishAnnouncement(ctx context.Context, req *pb.AnnouncementS) (*pb.AnnouncementR, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	if !user.IsAdmin() {
+		log.Printf("User %v is not allowed to publish announcements", user.GetUsername())
+		return &pb.AnnouncementR{Status: pb.AnnouncementR_PERMISSION_DENIED}, nil
+	}
+
+	recipients, err := publishAnnouncement(user.GetUsername(), req)
+	if err == errUnknownUser {
+		return &pb.AnnouncementR{Status: pb.AnnouncementR_UNKNOWN_USER}, nil
+	}
+	if err != nil {
+		return &pb.AnnouncementR{Status: pb.AnnouncementR_ERROR}, nil
+	}
+
+	return &pb.AnnouncementR{Status: pb.AnnouncementR_OK, Recipients: uint32(recipients)}, nil
+}
+
+// Announce sends an announcement to everyone and returns the number of streams
+// that received it.
+func Announce(author string, severity pb.Severity, message string) (int, error) {
+	return publishAnnouncement(author, &pb.AnnouncementS{Message: message, Severity: severity})
+}
+
+var errUnknownUser = errors.New("unknown user")
+
+// publishAnnouncement sends an announcement to its targets and returns the
+// number of streams that received it.
+func publishAnnouncement(author string, req *pb.AnnouncementS) (int, error) {
+	message := cleanText(req.Message)
+	if message == "" || len([]rune(message)) > maxMessageLength {
+		return 0, errors.New("invalid announcement")
+	}
+
+	targets := make(map[string]bool, len(req.Usernames))
+	for _, username := range req.Usernames {
+		if !communicationState.IsUserRegistered(username) {
+			return 0, errUnknownUser
+		}
+		targets[username] = true
+	}
+
+	announcement := &pb.SubAnnouncement{
+		Status:    pb.SubAnnouncement_OK,
+		Message:   message,
+		Severity:  req.Severity,
+		Author:    author,
+		Timestamp: timestamp(time.Now()),
+	}
+
+	recipients := announcementHub.publish(announcement, func(sub *subscriber) bool {
+		return targets[sub.username] || inRole(sub, req.Role, len(targets) == 0)
+	})
+
+	log.Printf("%v announcement from %v to %d streams: %v", req.Severity, author, recipients, message)
+	return recipients, nil
+}
+
+// inRole reports if a subscriber is in role, everyone only counts when the
+// announcement is not sent to specific users.
+func inRole(sub *subscriber, role pb.Role, everyone bool) bool {
+	switch role {
+	case pb.Role_ADMINS:
+		return sub.user.IsAdmin()
+	case pb.Role_USERS:
+		return !sub.user.IsAdmin()
+	}
+	return everyone
+}
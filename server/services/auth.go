@@ -2,34 +2,121 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"log"
+	"regexp"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
 	"github.com/corrreia/chatroom-grpc/server/types"
+	"github.com/corrreia/chatroom-grpc/utils"
 )
 
+const (
+	sessionSweepInterval = time.Minute     // how often expired sessions are ended
+	sessionIdleTimeout   = 5 * time.Minute // sessions without streams idle this long are ended
+)
+
+// usernamePattern keeps usernames printable and usable as file names, they
+// name client certificates.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// reservedUsernames cannot be registered in any case, they name the files of
+// the server or would impersonate it.
+var reservedUsernames = map[string]bool{
+	"ca":     true,
+	"server": true,
+	"admin":  true,
+}
+
 type authServer struct {
 	pb.UnimplementedAuthServiceServer
 }
 
 var authState *types.ServerState = nil
 
+// PublicMethods are the methods that can be called without a token
+var PublicMethods = []string{
+	"/AuthService/Login",
+	"/AuthService/Register",
+}
+
+// slowModeMethods are the methods limited by the slow mode, besides the
+// commands sending messages
+var slowModeMethods = []string{
+	"/ChatService/SendMessage",
+	"/ChatService/SendDirectMessage",
+}
+
+// SlowModeRequest reports whether the slow mode limits a request, because it
+// calls one of slowModeMethods or runs a command that sends a message.
+func SlowModeRequest(method string, req interface{}) bool {
+	if cmd, ok := req.(*pb.CommandS); ok {
+		c := commands.lookup(cmd.Command)
+		return c != nil && c.message
+	}
+
+	for _, slowed := range slowModeMethods {
+		if method == slowed {
+			return true
+		}
+	}
+	return false
+}
+
 func StartAuthServer(s *grpc.Server, state *types.ServerState) {
 	log.Printf("Starting Auth server")
 
 	authState = state
 	pb.RegisterAuthServiceServer(s, &authServer{})
+
+	go expireSessions()
+}
+
+// expireSessions periodically ends the sessions that were not refreshed in
+// time, closing their streams, and the ones idle without streams so they do
+// not count toward the connection limits. Users left without sessions log out.
+func expireSessions() {
+	streaming := func(session *types.Session) bool {
+		return sessionStreams.count(session.GetId()) > 0
+	}
+
+	for range time.Tick(sessionSweepInterval) {
+		for _, session := range authState.ExpireSessions(sessionIdleTimeout, streaming) {
+			user := session.GetUser()
+			sessionStreams.closeSession(session.GetId())
+			log.Printf("Session %v of user %v expired", session.GetId(), user.GetUsername())
+			if !user.IsConnected() {
+				publishPresence(user, pb.PresenceEvent_DISCONNECT)
+			}
+		}
+	}
 }
 
 func (s *authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
 	log.Printf("Login request from %v", req.Username)
 
+	// slow down password guessing, before anything else is checked
+	address := interceptors.PeerIP(ctx)
+	locked, wait := logins.check(req.Username, address)
+	if locked {
+		log.Printf("Audit: login for %v from %v refused, locked out", req.Username, address)
+		return &pb.LoginResponse{Status: pb.LoginResponse_ACCOUNT_LOCKED}, nil
+	}
+	if wait > 0 {
+		return nil, interceptors.RetryAfter(ctx, wait)
+	}
+
 	// check if user exists
 	user := authState.GetUserByUsername(req.Username)
-	//id is User{} if user does not exist
-	if user.GetId() == "" {
+	if user == nil {
+		logins.fail(req.Username, address)
 		return &pb.LoginResponse{Status: pb.LoginResponse_INVALID_CREDENTIALS}, nil
 	}
 
@@ -39,23 +126,217 @@ func (s *authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Login
 		return &pb.LoginResponse{Status: pb.LoginResponse_USER_BANNED}, nil
 	}
 
-	// check if user is already connected
-	if user.IsConnected() {
-		log.Printf("User %v is already connected", req.Username)
-		return &pb.LoginResponse{Status: pb.LoginResponse_ALREADY_LOGGED_IN}, nil
-	}
-
 	// check if password is correct
 	if !user.CheckPassword(req.Password) {
 		log.Printf("Invalid password for user %v", req.Username)
+		logins.fail(req.Username, address)
 		return &pb.LoginResponse{Status: pb.LoginResponse_INVALID_CREDENTIALS}, nil
 	}
+	logins.succeed(req.Username)
+
+	// open a session if the connection limits allow it, admins can log in
+	// when the server is full
+	firstSession := !user.IsConnected()
+	session, err := authState.CreateSession(user, address, req.Client)
+	switch err {
+	case nil:
+	case types.ErrServerFull:
+		log.Printf("Server is full, user %v cannot log in", req.Username)
+		return &pb.LoginResponse{Status: pb.LoginResponse_SERVER_FULL}, nil
+	case types.ErrTooManyConnections:
+		log.Printf("Too many connections from %v, user %v cannot log in", address, req.Username)
+		return &pb.LoginResponse{Status: pb.LoginResponse_TOO_MANY_CONNECTIONS}, nil
+	default:
+		return nil, status.Error(codes.Internal, "could not log in")
+	}
+
+	user.Touch()
+	log.Printf("User %v logged in from %v (session %v)", req.Username, address, session.GetId())
+	if firstSession {
+		publishPresence(user, pb.PresenceEvent_LOGIN)
+	}
+
+	return &pb.LoginResponse{
+		Status:    pb.LoginResponse_SUCCESS,
+		Token:     session.GetToken(),
+		Expires:   timestamp(session.GetExpires()),
+		SessionId: session.GetId(),
+	}, nil
+}
+
+func (s *authServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	session, ok := interceptors.SessionFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	user := session.GetUser()
+	if err := endSession(session, pb.PresenceEvent_LOGOUT); err != nil {
+		log.Printf("Could not log out user %v: %v", user.GetUsername(), err)
+		return nil, status.Error(codes.Internal, "could not log out")
+	}
+	log.Printf("User %v logged out (session %v)", user.GetUsername(), session.GetId())
+
+	return &pb.LogoutResponse{Status: pb.LogoutResponse_SUCCESS}, nil
+}
+
+// Refresh gives the session of the caller a new token and expiry, the old
+// token stops working.
+func (s *authServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	session, ok := interceptors.SessionFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	if err := authState.RefreshSession(session); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "session ended")
+	}
+
+	return &pb.RefreshResponse{Token: session.GetToken(), Expires: timestamp(session.GetExpires())}, nil
+}
+
+// ListSessions returns the sessions of the caller, oldest first.
+func (s *authServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	current, ok := interceptors.SessionFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	var sessions []*pb.Session
+	for _, session := range authState.GetUserSessions(current.GetUser()) {
+		sessions = append(sessions, &pb.Session{
+			Id:         session.GetId(),
+			Client:     session.GetClient(),
+			Address:    session.GetAddress(),
+			Created:    timestamp(session.GetCreated()),
+			LastActive: timestamp(session.GetLastActive()),
+			Expires:    timestamp(session.GetExpires()),
+			Current:    session == current,
+		})
+	}
+
+	return &pb.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// RevokeSession ends one of the sessions of the caller, it can be the
+// current one.
+func (s *authServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	current, ok := interceptors.SessionFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	// users can only revoke their own sessions, others look like they do not exist
+	user := current.GetUser()
+	session := authState.GetSessionById(req.SessionId)
+	if session == nil || session.GetUser() != user {
+		return &pb.RevokeSessionResponse{Status: pb.RevokeSessionResponse_NOT_FOUND}, nil
+	}
+
+	if err := endSession(session, pb.PresenceEvent_LOGOUT); err != nil {
+		return &pb.RevokeSessionResponse{Status: pb.RevokeSessionResponse_NOT_FOUND}, nil
+	}
+	log.Printf("Session %v of user %v revoked", session.GetId(), user.GetUsername())
+
+	return &pb.RevokeSessionResponse{Status: pb.RevokeSessionResponse_SUCCESS}, nil
+}
 
-	// login user
-	user.SetConnected(true)
-	user.RegenerateToken()
-	log.Printf("User %v logged in", req.Username)
+// KickUser disconnects a user, it can log in again.
+func KickUser(user *types.User) error {
+	if err := disconnectUser(user, pb.PresenceEvent_DISCONNECT); err != nil {
+		return err
+	}
 
-	return &pb.LoginResponse{Status: pb.LoginResponse_SUCCESS, Token: user.GetToken()}, nil
+	log.Printf("User %v was kicked", user.GetUsername())
+	return nil
 }
 
+// endSession logs a session out and closes the streams opened with it. When
+// it was the last session of the user the others get the given presence event.
+func endSession(session *types.Session, event pb.PresenceEvent_Type) error {
+	if err := authState.EndSession(session); err != nil {
+		return err
+	}
+
+	sessionStreams.closeSession(session.GetId())
+	if user := session.GetUser(); !user.IsConnected() {
+		publishPresence(user, event)
+	}
+	return nil
+}
+
+// detachSession ends a session whose streams all closed without a logout,
+// like when its client crashed or lost the connection, unless it opened a
+// stream again. Sessions already ended are ignored.
+func detachSession(id string) {
+	session := authState.GetSessionById(id)
+	if session == nil || sessionStreams.count(id) > 0 {
+		return
+	}
+
+	if err := endSession(session, pb.PresenceEvent_DISCONNECT); err != nil {
+		return
+	}
+	log.Printf("Session %v of user %v ended, its streams closed", id, session.GetUser().GetUsername())
+}
+
+// disconnectUser logs out every session of a user, closing the streams opened
+// with them. The other users get the given presence event.
+func disconnectUser(user *types.User, event pb.PresenceEvent_Type) error {
+	for _, session := range authState.EndUserSessions(user) {
+		sessionStreams.closeSession(session.GetId())
+	}
+
+	publishPresence(user, event)
+	return nil
+}
+
+func (s *authServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	log.Printf("Register request from %v", req.Username)
+
+	if req.Username == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+	if !usernamePattern.MatchString(req.Username) || reservedUsernames[strings.ToLower(req.Username)] {
+		return nil, status.Error(codes.InvalidArgument, "usernames are up to 32 letters, digits, _ or - and cannot be reserved names")
+	}
+
+	// check server password, an empty one means the server is open
+	serverPass := authState.GetServerPassword()
+	if serverPass != "" && subtle.ConstantTimeCompare([]byte(req.ServerPassword), []byte(serverPass)) != 1 {
+		log.Printf("Invalid server password for user %v", req.Username)
+		return &pb.RegisterResponse{Status: pb.RegisterResponse_INVALID_SERVER_PASSWORD}, nil
+	}
+
+	// check if username is taken
+	if authState.IsUserRegistered(req.Username) {
+		log.Printf("Username %v already exists", req.Username)
+		return &pb.RegisterResponse{Status: pb.RegisterResponse_USERNAME_EXISTS}, nil
+	}
+
+	hash, err := utils.GenerateHash(req.Password)
+	if err != nil {
+		log.Printf("Could not hash password for user %v: %v", req.Username, err)
+		return nil, status.Error(codes.Internal, "could not register user")
+	}
+
+	// register user, AddUser checks the username again in case of a concurrent register
+	user := types.NewUser(utils.GenerateId(), req.Username, hash)
+	switch err := authState.AddUser(user); err {
+	case nil:
+	case types.ErrUserExists:
+		log.Printf("Username %v already exists", req.Username)
+		return &pb.RegisterResponse{Status: pb.RegisterResponse_USERNAME_EXISTS}, nil
+	default:
+		log.Printf("Could not register user %v: %v", req.Username, err)
+		return nil, status.Error(codes.Internal, "could not register user")
+	}
+	if room := authState.GetRoom(types.DefaultRoom); room != nil {
+		if err := authState.JoinRoom(room, user); err != nil {
+			log.Printf("User %v could not join room %v: %v", req.Username, types.DefaultRoom, err)
+		}
+	}
+	log.Printf("User %v registered", req.Username)
+
+	return &pb.RegisterResponse{Status: pb.RegisterResponse_SUCCESS}, nil
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+)
+
+func TestSlowModeRequest(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		req    interface{}
+		slowed bool
+	}{
+		{method: "/ChatService/SendMessage", req: &pb.MessageS{}, slowed: true},
+		{method: "/ChatService/SendDirectMessage", req: &pb.DirectMessageS{}, slowed: true},
+		{method: "/CommandService/SendCommand", req: &pb.CommandS{Command: "me"}, slowed: true},
+		{method: "/CommandService/SendCommand", req: &pb.CommandS{Command: "/ME"}, slowed: true},
+		{method: "/CommandService/SendCommand", req: &pb.CommandS{Command: "who"}, slowed: false},
+		{method: "/CommandService/SendCommand", req: &pb.CommandS{Command: "unknown"}, slowed: false},
+		{method: "/AuthService/Login", req: &pb.LoginRequest{}, slowed: false},
+	} {
+		if got := SlowModeRequest(tc.method, tc.req); got != tc.slowed {
+			t.Errorf("%v %v: got %v, want %v", tc.method, tc.req, got, tc.slowed)
+		}
+	}
+}
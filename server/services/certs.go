@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// certSessions keeps the session opened for each client certificate, so the
+// requests made with the same certificate share it like they would share a
+// token.
+var certSessions = struct {
+	mu       sync.Mutex
+	sessions map[[sha256.Size]byte]*types.Session // certificate fingerprint: session
+}{sessions: make(map[[sha256.Size]byte]*types.Session)}
+
+// CertLogin returns the session of a user authenticated with a client
+// certificate. The first request opens one like a login with a password, and
+// expired sessions are renewed as long as the certificate is valid.
+func CertLogin(ctx context.Context, user *types.User, cert *x509.Certificate) (*types.Session, error) {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	certSessions.mu.Lock()
+	defer certSessions.mu.Unlock()
+
+	if session, ok := certSessions.sessions[fingerprint]; ok && authState.GetSessionById(session.GetId()) != nil {
+		if !session.IsExpired() || authState.RefreshSession(session) == nil {
+			return session, nil
+		}
+	}
+
+	address := interceptors.PeerIP(ctx)
+	firstSession := !user.IsConnected()
+	session, err := authState.CreateSession(user, address, fmt.Sprintf("certificate %x", cert.SerialNumber))
+	switch err {
+	case nil:
+	case types.ErrServerFull:
+		return nil, status.Error(codes.ResourceExhausted, "server is full")
+	case types.ErrTooManyConnections:
+		return nil, status.Error(codes.ResourceExhausted, "too many connections from the address")
+	default:
+		return nil, status.Error(codes.Internal, "could not log in")
+	}
+	certSessions.sessions[fingerprint] = session
+
+	// forget the sessions that ended, they are not used again
+	for key, other := range certSessions.sessions {
+		if authState.GetSessionById(other.GetId()) == nil {
+			delete(certSessions.sessions, key)
+		}
+	}
+
+	user.Touch()
+	log.Printf("Audit: user %v logged in with a client certificate from %v (session %v)", user.GetUsername(), address, session.GetId())
+	if firstSession {
+		publishPresence(user, pb.PresenceEvent_LOGIN)
+	}
+
+	return session, nil
+}
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// argument describes a command argument.
+type argument struct {
+	name     string
+	optional bool
+	variadic bool // takes the rest of the arguments, only valid as the last one
+}
+
+// command is a slash command that can be run with SendCommand.
+type command struct {
+	name  string
+	args  []argument
+	help  string
+	admin bool // only admins can run it
+
+	message bool // sends a message to a room, limited by the slow mode
+
+	// run executes the command typed in a room, args were already checked
+	// against the schema and a variadic argument is joined into one
+	run func(user *types.User, room string, args []string) *pb.CommandR
+}
+
+// usage returns the command with its arguments, like /kick <user> [reason...]
+func (c *command) usage() string {
+	var b strings.Builder
+	b.WriteString("/" + c.name)
+
+	for _, arg := range c.args {
+		name := arg.name
+		if arg.variadic {
+			name += "..."
+		}
+
+		if arg.optional {
+			b.WriteString(" [" + name + "]")
+		} else {
+			b.WriteString(" <" + name + ">")
+		}
+	}
+
+	return b.String()
+}
+
+// parseArgs checks the arguments against the schema of the command.
+func (c *command) parseArgs(args []string) ([]string, bool) {
+	required, max := 0, len(c.args)
+	for _, arg := range c.args {
+		if !arg.optional {
+			required++
+		}
+		if arg.variadic {
+			max = -1
+		}
+	}
+
+	if len(args) < required || (max >= 0 && len(args) > max) {
+		return nil, false
+	}
+
+	// join the rest of the arguments into the variadic one
+	if max < 0 && len(args) >= len(c.args) {
+		last := len(c.args) - 1
+		args = append(args[:last:last], strings.Join(args[last:], " "))
+	}
+
+	return args, true
+}
+
+// commandRegistry holds the commands by name.
+type commandRegistry struct {
+	commands map[string]*command
+}
+
+var commands = newCommandRegistry()
+
+// newCommandRegistry returns a registry with the built-in commands.
+func newCommandRegistry() *commandRegistry {
+	r := &commandRegistry{
+		commands: make(map[string]*command),
+	}
+
+	r.register(&command{
+		name: "help",
+		args: []argument{{name: "command", optional: true}},
+		help: "list the commands or show the help of one",
+		run:  r.help,
+	})
+	r.register(&command{
+		name: "who",
+		help: "list the connected users",
+		run:  whoCommand,
+	})
+	r.register(&command{
+		name:    "me",
+		args:    []argument{{name: "action", variadic: true}},
+		help:    "send an action to the current room, like /me waves",
+		message: true,
+		run:     meCommand,
+	})
+	r.register(&command{
+		name:  "slowmode",
+		args:  []argument{{name: "seconds|off", optional: true}},
+		help:  "show or change the time users wait between messages",
+		admin: true,
+		run:   slowModeCommand,
+	})
+	r.register(&command{
+		name:  "announce",
+		args:  []argument{{name: "info|warning|critical"}, {name: "message", variadic: true}},
+		help:  "send an announcement to everyone",
+		admin: true,
+		run:   announceCommand,
+	})
+	r.register(&command{
+		name:  "kick",
+		args:  []argument{{name: "user"}, {name: "reason", optional: true, variadic: true}},
+		help:  "disconnect a user",
+		admin: true,
+		run:   kickCommand,
+	})
+	r.register(&command{
+		name:  "ban",
+		args:  []argument{{name: "user"}, {name: "reason", optional: true, variadic: true}},
+		help:  "ban a user and disconnect it",
+		admin: true,
+		run:   banCommand,
+	})
+	r.register(&command{
+		name:  "unban",
+		args:  []argument{{name: "user"}},
+		help:  "allow a banned user to log in again",
+		admin: true,
+		run:   unbanCommand,
+	})
+	r.register(&command{
+		name:  "lockouts",
+		help:  "list the accounts and addresses locked after failed logins",
+		admin: true,
+		run:   lockoutsCommand,
+	})
+	r.register(&command{
+		name:  "unlock",
+		args:  []argument{{name: "user|ip"}},
+		help:  "clear the failed logins of an account or address",
+		admin: true,
+		run:   unlockCommand,
+	})
+	r.register(&command{
+		name:  "op",
+		args:  []argument{{name: "user"}},
+		help:  "make a user an admin",
+		admin: true,
+		run:   opCommand(true),
+	})
+	r.register(&command{
+		name:  "deop",
+		args:  []argument{{name: "user"}},
+		help:  "remove the admin rights of a user",
+		admin: true,
+		run:   opCommand(false),
+	})
+
+	return r
+}
+
+func (r *commandRegistry) register(c *command) {
+	r.commands[c.name] = c
+}
+
+// lookup finds a command by name, with or without the leading slash.
+func (r *commandRegistry) lookup(name string) *command {
+	return r.commands[strings.ToLower(strings.TrimPrefix(name, "/"))]
+}
+
+// list returns the commands a user can run sorted by name.
+func (r *commandRegistry) list(user *types.User) []*command {
+	var list []*command
+	for _, c := range r.commands {
+		if !c.admin || user.IsAdmin() {
+			list = append(list, c)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].name < list[j].name
+	})
+	return list
+}
+
+// execute runs a command for a user checking its privilege and arguments.
+func (r *commandRegistry) execute(user *types.User, room string, name string, args []string) *pb.CommandR {
+	c := r.lookup(name)
+	if c == nil {
+		return commandResult(pb.CommandR_UNKNOWN_COMMAND, fmt.Sprintf("Unknown command %v, try /help", name))
+	}
+
+	if c.admin && !user.IsAdmin() {
+		log.Printf("User %v is not allowed to run /%v", user.GetUsername(), c.name)
+		return commandResult(pb.CommandR_PERMISSION_DENIED, fmt.Sprintf("Only admins can use /%v", c.name))
+	}
+
+	parsed, ok := c.parseArgs(args)
+	if !ok {
+		return commandResult(pb.CommandR_INVALID_ARGUMENTS, "Usage: "+c.usage())
+	}
+
+	log.Printf("User %v ran /%v %v", user.GetUsername(), c.name, strings.Join(args, " "))
+	return c.run(user, room, parsed)
+}
+
+func commandResult(status pb.CommandR_Status, message string, lines ...string) *pb.CommandR {
+	return &pb.CommandR{Status: status, Message: message, Lines: lines}
+}
+
+func (r *commandRegistry) help(user *types.User, room string, args []string) *pb.CommandR {
+	if len(args) == 1 {
+		c := r.lookup(args[0])
+		if c == nil || (c.admin && !user.IsAdmin()) {
+			return commandResult(pb.CommandR_UNKNOWN_COMMAND, fmt.Sprintf("Unknown command %v", args[0]))
+		}
+		return commandResult(pb.CommandR_OK, c.usage(), c.help)
+	}
+
+	var lines []string
+	for _, c := range r.list(user) {
+		lines = append(lines, fmt.Sprintf("%v - %v", c.usage(), c.help))
+	}
+	return commandResult(pb.CommandR_OK, "Available commands", lines...)
+}
+
+func whoCommand(user *types.User, room string, args []string) *pb.CommandR {
+	var lines []string
+	for _, u := range communicationState.GetConnectedUserList() {
+		if u.IsAdmin() {
+			lines = append(lines, u.GetUsername()+" (admin)")
+		} else {
+			lines = append(lines, u.GetUsername())
+		}
+	}
+
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("%d users connected", len(lines)), lines...)
+}
+
+func meCommand(user *types.User, room string, args []string) *pb.CommandR {
+	action := cleanText(args[0])
+	if action == "" || len([]rune(action)) > maxMessageLength {
+		return commandResult(pb.CommandR_INVALID_ARGUMENTS, "Usage: /me <action...>")
+	}
+
+	target, err := memberRoom(user, room)
+	if err != nil {
+		return commandResult(pb.CommandR_ERROR, fmt.Sprintf("You are not in room %v", room))
+	}
+
+	if err := broadcastMessage(target, types.Message{Sender: user.GetUsername(), Text: action, Action: true}); err != nil {
+		return commandResult(pb.CommandR_ERROR, "Could not send the action")
+	}
+	return commandResult(pb.CommandR_OK, "")
+}
+
+// targetUser finds the user a moderation command acts on, admins cannot use
+// them on themselves.
+func targetUser(user *types.User, username string) (*types.User, *pb.CommandR) {
+	target := communicationState.GetUserByUsername(username)
+	if target == nil {
+		return nil, commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v does not exist", username))
+	}
+
+	if target == user {
+		return nil, commandResult(pb.CommandR_ERROR, "You cannot use this command on yourself")
+	}
+
+	return target, nil
+}
+
+// withReason appends the reason of a moderation command to a message
+func withReason(message string, args []string) string {
+	if len(args) > 1 && args[1] != "" {
+		return message + ": " + args[1]
+	}
+	return message
+}
+
+func kickCommand(user *types.User, room string, args []string) *pb.CommandR {
+	target, result := targetUser(user, args[0])
+	if result != nil {
+		return result
+	}
+
+	if !target.IsConnected() {
+		return commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v is not connected", target.GetUsername()))
+	}
+
+	if err := disconnectUser(target, pb.PresenceEvent_DISCONNECT); err != nil {
+		log.Printf("Could not kick user %v: %v", target.GetUsername(), err)
+		return commandResult(pb.CommandR_ERROR, "Could not kick the user")
+	}
+
+	log.Print(withReason(fmt.Sprintf("User %v was kicked by %v", target.GetUsername(), user.GetUsername()), args))
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Kicked %v", target.GetUsername()))
+}
+
+func banCommand(user *types.User, room string, args []string) *pb.CommandR {
+	target, result := targetUser(user, args[0])
+	if result != nil {
+		return result
+	}
+
+	if target.IsBanned() {
+		return commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v is already banned", target.GetUsername()))
+	}
+
+	if err := communicationState.SetUserBanned(target, true); err != nil {
+		log.Printf("Could not ban user %v: %v", target.GetUsername(), err)
+		return commandResult(pb.CommandR_ERROR, "Could not ban the user")
+	}
+
+	if target.IsConnected() {
+		if err := disconnectUser(target, pb.PresenceEvent_DISCONNECT); err != nil {
+			log.Printf("Could not disconnect user %v: %v", target.GetUsername(), err)
+		}
+	}
+
+	log.Print(withReason(fmt.Sprintf("User %v was banned by %v", target.GetUsername(), user.GetUsername()), args))
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Banned %v", target.GetUsername()))
+}
+
+func unbanCommand(user *types.User, room string, args []string) *pb.CommandR {
+	target, result := targetUser(user, args[0])
+	if result != nil {
+		return result
+	}
+
+	if !target.IsBanned() {
+		return commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v is not banned", target.GetUsername()))
+	}
+
+	if err := communicationState.SetUserBanned(target, false); err != nil {
+		log.Printf("Could not unban user %v: %v", target.GetUsername(), err)
+		return commandResult(pb.CommandR_ERROR, "Could not unban the user")
+	}
+
+	log.Printf("User %v was unbanned by %v", target.GetUsername(), user.GetUsername())
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Unbanned %v", target.GetUsername()))
+}
+
+func lockoutsCommand(user *types.User, room string, args []string) *pb.CommandR {
+	var lines []string
+	for _, lockout := range Lockouts() {
+		lines = append(lines, fmt.Sprintf("%v, %d failures, until %v", lockout.Key, lockout.Failures, lockout.Until.Format("15:04:05")))
+	}
+
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("%d lockouts", len(lines)), lines...)
+}
+
+func unlockCommand(user *types.User, room string, args []string) *pb.CommandR {
+	if !ClearLockout(args[0], user.GetUsername()) {
+		return commandResult(pb.CommandR_ERROR, fmt.Sprintf("%v has no failed logins", args[0]))
+	}
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Cleared the failed logins of %v", args[0]))
+}
+
+// opCommand returns the run function of /op and /deop.
+func opCommand(admin bool) func(user *types.User, room string, args []string) *pb.CommandR {
+	return func(user *types.User, room string, args []string) *pb.CommandR {
+		target, result := targetUser(user, args[0])
+		if result != nil {
+			return result
+		}
+
+		if target.IsAdmin() == admin {
+			if admin {
+				return commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v is already an admin", target.GetUsername()))
+			}
+			return commandResult(pb.CommandR_ERROR, fmt.Sprintf("User %v is not an admin", target.GetUsername()))
+		}
+
+		if err := communicationState.SetUserAdmin(target, admin); err != nil {
+			log.Printf("Could not change the admin rights of user %v: %v", target.GetUsername(), err)
+			return commandResult(pb.CommandR_ERROR, "Could not change the admin rights of the user")
+		}
+
+		if admin {
+			log.Printf("User %v was made an admin by %v", target.GetUsername(), user.GetUsername())
+			return commandResult(pb.CommandR_OK, fmt.Sprintf("%v is now an admin", target.GetUsername()))
+		}
+		log.Printf("User %v is no longer an admin, removed by %v", target.GetUsername(), user.GetUsername())
+		return commandResult(pb.CommandR_OK, fmt.Sprintf("%v is no longer an admin", target.GetUsername()))
+	}
+}
+
+func slowModeCommand(user *types.User, room string, args []string) *pb.CommandR {
+	if len(args) == 0 {
+		if interval := communicationState.GetSlowMode(); interval > 0 {
+			return commandResult(pb.CommandR_OK, fmt.Sprintf("Slow mode is on, one message every %v", interval))
+		}
+		return commandResult(pb.CommandR_OK, "Slow mode is off")
+	}
+
+	interval, ok := ParseSlowMode(args[0])
+	if !ok {
+		return commandResult(pb.CommandR_INVALID_ARGUMENTS, "Usage: /slowmode [seconds|off]")
+	}
+
+	SetSlowMode(user.GetUsername(), interval)
+	if interval == 0 {
+		return commandResult(pb.CommandR_OK, "Slow mode is off")
+	}
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Slow mode is on, one message every %v", interval))
+}
+
+// ParseSlowMode reads a slow mode interval in seconds, off or 0 turn it off.
+func ParseSlowMode(s string) (time.Duration, bool) {
+	if strings.ToLower(s) == "off" {
+		return 0, true
+	}
+
+	seconds, err := strconv.Atoi(s)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// SetSlowMode changes the slow mode and tells everyone about it.
+func SetSlowMode(author string, interval time.Duration) {
+	communicationState.SetSlowMode(interval)
+
+	message := "Slow mode is off"
+	if interval > 0 {
+		message = fmt.Sprintf("Slow mode is on, you can send one message every %v", interval)
+	}
+	log.Printf("%v, changed by %v", message, author)
+	Announce(author, pb.Severity_INFO, message)
+}
+
+func announceCommand(user *types.User, room string, args []string) *pb.CommandR {
+	severity, ok := pb.Severity_value[strings.ToUpper(args[0])]
+	if !ok {
+		return commandResult(pb.CommandR_INVALID_ARGUMENTS, "Usage: /announce <info|warning|critical> <message...>")
+	}
+
+	recipients, err := publishAnnouncement(user.GetUsername(), &pb.AnnouncementS{
+		Message:  args[1],
+		Severity: pb.Severity(severity),
+	})
+	if err != nil {
+		return commandResult(pb.CommandR_ERROR, "Could not send the announcement")
+	}
+
+	return commandResult(pb.CommandR_OK, fmt.Sprintf("Announcement sent to %d streams", recipients))
+}
@@ -1,28 +1,197 @@
 package services
 
 import (
+	"context"
 	"log"
+	"strings"
+	"unicode"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
 	"github.com/corrreia/chatroom-grpc/server/types"
 )
 
+const (
+	maxMessageLength = 1000 // maximum number of characters in a chat message
+	maxBacklog       = 500  // maximum number of messages replayed on subscribe
+)
+
 type communicationServer struct {
 	pb.UnimplementedAnnouncementServiceServer
 	pb.UnimplementedChatServiceServer
 	pb.UnimplementedCommandServiceServer
+	pb.UnimplementedPresenceServiceServer
 }
 
 var communicationState *types.ServerState = nil
 
+var chatHub = newHub()
+
 func StartCommunicationServer(s *grpc.Server, state *types.ServerState) {
 	log.Printf("Starting Communication server")
 
-	pb.RegisterAnnouncementServiceServer(s, &communicationServer{})
-	pb.RegisterChatServiceServer(s, &communicationServer{})
-	pb.RegisterCommandServiceServer(s, &communicationServer{})
+	communicationState = state
+	server := &communicationServer{}
+	pb.RegisterAnnouncementServiceServer(s, server)
+	pb.RegisterChatServiceServer(s, server)
+	pb.RegisterCommandServiceServer(s, server)
+	pb.RegisterPresenceServiceServer(s, server)
+
+	go watchIdle()
 }
 
-// func (s *communicationServer) SendAnnouncement(ctx context.Context, req *pb.AnnouncementRequest) (*pb.AnnouncementResponse, error) {
+func (s *communicationServer) SendMessage(ctx context.Context, req *pb.MessageS) (*pb.MessageR, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	markActive(user)
+
+	message := cleanText(req.Message)
+	if message == "" || len([]rune(message)) > maxMessageLength {
+		return &pb.MessageR{Status: pb.MessageR_ERROR}, nil
+	}
+
+	room, err := memberRoom(user, req.Room)
+	if err == errRoomNotFound {
+		return &pb.MessageR{Status: pb.MessageR_ROOM_NOT_FOUND}, nil
+	}
+	if err == errNotAMember {
+		return &pb.MessageR{Status: pb.MessageR_NOT_A_MEMBER}, nil
+	}
+
+	if err := broadcastMessage(room, types.Message{Sender: user.GetUsername(), Text: message}); err != nil {
+		return &pb.MessageR{Status: pb.MessageR_ERROR}, nil
+	}
+
+	return &pb.MessageR{Status: pb.MessageR_OK}, nil
+}
+
+func (s *communicationServer) SendCommand(ctx context.Context, req *pb.CommandS) (*pb.CommandR, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	markActive(user)
+	return commands.execute(user, roomName(req.Room), req.Command, req.Args), nil
+}
+
+// cleanText trims a text sent by a user and drops the runes that are not
+// printable, like control characters and the escape starting terminal
+// sequences. Other whitespace becomes a space.
+func cleanText(text string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsPrint(r):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, text))
+}
+
+// broadcastMessage stores a message in the history of a room and sends it to
+// the subscribers that are members of the room.
+func broadcastMessage(room *types.Room, message types.Message) error {
+	message.Room = room.GetName()
+	stored, err := communicationState.AddMessage(message)
+	if err != nil {
+		log.Printf("Could not store message from %v: %v", message.Sender, err)
+		return err
+	}
+
+	chatHub.publish(messageToProto(stored), inRoom(room))
+	return nil
+}
+
+func (s *communicationServer) SubscribeMessage(req *pb.SubRequest, stream pb.ChatService_SubscribeMessageServer) error {
+	user, ok := interceptors.UserFromContext(stream.Context())
+	if !ok {
+		return status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	// the stream is closed when the session logs out
+	session, _ := interceptors.SessionFromContext(stream.Context())
+	ctx, done := sessionStreams.register(stream.Context(), session.GetId())
+	defer done()
+
+	// subscribe before reading the history so no message is missed in between
+	sub := chatHub.subscribe(user)
+	defer chatHub.unsubscribe(sub)
+	log.Printf("User %v subscribed to messages", user.GetUsername())
+
+	sent, err := sendBacklog(user, req, stream)
+	if err != nil {
+		return err
+	}
+
+	if err := sendQueued(user, sent, stream); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("User %v unsubscribed from messages", user.GetUsername())
+			return nil
+		case item := <-sub.ch:
+			msg := item.(*pb.SubMessage)
+			if sent[msg.Id] { // already sent with the backlog or the queue
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendBacklog replays the history of the rooms the user is in as asked for in
+// the request and returns the ids of the messages sent.
+func sendBacklog(user *types.User, req *pb.SubRequest, stream pb.ChatService_SubscribeMessageServer) (map[uint64]bool, error) {
+	sent := make(map[uint64]bool)
+	if req.Backlog == 0 && req.SinceId == 0 {
+		return sent, nil
+	}
+
+	limit := int(req.Backlog)
+	if limit == 0 || limit > maxBacklog {
+		limit = maxBacklog
+	}
+
+	messages, err := communicationState.GetMessages(req.SinceId, limit, visibleTo(user))
+	if err != nil {
+		log.Printf("Could not load message history: %v", err)
+		return nil, status.Error(codes.Internal, "could not load message history")
+	}
+
+	for _, message := range messages {
+		if err := stream.Send(messageToProto(message)); err != nil {
+			return nil, err
+		}
+		sent[message.Id] = true
+	}
+
+	return sent, nil
+}
+
+func messageToProto(message types.Message) *pb.SubMessage {
+	return &pb.SubMessage{
+		Status:    pb.SubMessage_OK,
+		Message:   message.Text,
+		Sender:    message.Sender,
+		Id:        message.Id,
+		Timestamp: timestamp(message.Time),
+		Action:    message.Action,
+		Room:      message.Room,
+		Recipient: message.Recipient,
+	}
+}
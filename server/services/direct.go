@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+func (s *communicationServer) SendDirectMessage(ctx context.Context, req *pb.DirectMessageS) (*pb.DirectMessageR, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	markActive(user)
+
+	message := cleanText(req.Message)
+	if message == "" || len([]rune(message)) > maxMessageLength {
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_ERROR}, nil
+	}
+
+	recipient := communicationState.GetUserByUsername(req.Recipient)
+	if recipient == nil || recipient == user {
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_UNKNOWN_USER}, nil
+	}
+
+	stored, err := communicationState.AddMessage(types.Message{
+		Sender:    user.GetUsername(),
+		Recipient: recipient.GetUsername(),
+		Text:      message,
+	})
+	if err != nil {
+		log.Printf("Could not store direct message from %v: %v", user.GetUsername(), err)
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_ERROR}, nil
+	}
+
+	// the sender sees its message on every session
+	item := messageToProto(stored)
+	chatHub.publish(item, isUser(user))
+	if chatHub.publish(item, isUser(recipient)) > 0 {
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_OK}, nil
+	}
+
+	// nobody is listening for the recipient, keep it for its next subscription
+	err = communicationState.QueueMessage(recipient, stored)
+	if err == types.ErrQueueFull {
+		log.Printf("Direct message queue of %v is full", recipient.GetUsername())
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_QUEUE_FULL}, nil
+	}
+	if err != nil {
+		log.Printf("Could not queue direct message for %v: %v", recipient.GetUsername(), err)
+		return &pb.DirectMessageR{Status: pb.DirectMessageR_ERROR}, nil
+	}
+
+	return &pb.DirectMessageR{Status: pb.DirectMessageR_QUEUED}, nil
+}
+
+// isUser returns a hub filter that accepts the streams of one user.
+func isUser(user *types.User) func(sub *subscriber) bool {
+	return func(sub *subscriber) bool {
+		return sub.user == user
+	}
+}
+
+// sendQueued delivers the direct messages queued while the user was not
+// connected that are not in sent, the ids already sent on the stream, and
+// adds the ones it delivers to sent.
+func sendQueued(user *types.User, sent map[uint64]bool, stream pb.ChatService_SubscribeMessageServer) error {
+	messages, err := communicationState.TakeQueuedMessages(user)
+	if err != nil {
+		log.Printf("Could not load queued messages of %v: %v", user.GetUsername(), err)
+		return nil
+	}
+
+	for i, message := range messages {
+		if sent[message.Id] { // already sent with the backlog
+			continue
+		}
+		if err := stream.Send(messageToProto(message)); err != nil {
+			// keep what could not be delivered for the next subscription
+			for _, rest := range messages[i:] {
+				communicationState.QueueMessage(user, rest)
+			}
+			return err
+		}
+		sent[message.Id] = true
+	}
+
+	if len(messages) > 0 {
+		log.Printf("Delivered %d queued messages to %v", len(messages), user.GetUsername())
+	}
+	return nil
+}
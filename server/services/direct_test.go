@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/storage"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// messageStream records the messages sent on a subscription.
+type messageStream struct {
+	grpc.ServerStream
+	sent []*pb.SubMessage
+}
+
+func (s *messageStream) Send(msg *pb.SubMessage) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestQueuedMessageOutsideBacklog(t *testing.T) {
+	communicationState = types.NewServerState(storage.NewMemoryStorage())
+	defer func() { communicationState = nil }()
+
+	alice := types.NewUser("1", "alice", "")
+	bob := types.NewUser("2", "bob", "")
+	for _, user := range []*types.User{alice, bob} {
+		if err := communicationState.AddUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	room, err := communicationState.CreateRoom("general", "", alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := communicationState.JoinRoom(room, bob); err != nil {
+		t.Fatal(err)
+	}
+
+	// bob is offline when alice writes to him, then the room moves on
+	dm, err := communicationState.AddMessage(types.Message{Sender: "alice", Recipient: "bob", Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := communicationState.QueueMessage(bob, dm); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 60; i++ {
+		if _, err := communicationState.AddMessage(types.Message{Sender: "alice", Room: "general", Text: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stream := &messageStream{}
+	sent, err := sendBacklog(bob, &pb.SubRequest{Backlog: 50}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sendQueued(bob, sent, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stream.sent) != 51 {
+		t.Fatalf("got %d messages, want the backlog of 50 and the queued one", len(stream.sent))
+	}
+	if last := stream.sent[50]; last.Id != dm.Id || last.Recipient != "bob" {
+		t.Fatalf("got %+v, want the queued direct message", last)
+	}
+	if !sent[dm.Id] {
+		t.Fatal("the queued message is not marked as sent")
+	}
+}
+
+func TestQueuedMessageInBacklog(t *testing.T) {
+	communicationState = types.NewServerState(storage.NewMemoryStorage())
+	defer func() { communicationState = nil }()
+
+	bob := types.NewUser("2", "bob", "")
+	if err := communicationState.AddUser(bob); err != nil {
+		t.Fatal(err)
+	}
+
+	dm, err := communicationState.AddMessage(types.Message{Sender: "alice", Recipient: "bob", Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := communicationState.QueueMessage(bob, dm); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := &messageStream{}
+	sent, err := sendBacklog(bob, &pb.SubRequest{Backlog: 50}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sendQueued(bob, sent, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stream.sent) != 1 {
+		t.Fatalf("got %d messages, want the direct message once", len(stream.sent))
+	}
+}
@@ -1,34 +1,273 @@
 package services
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io/ioutil"
 	"log"
 	"net"
-	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/ipv4"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/types"
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+const (
+	helloVersion     = 2    // version of the hello protocol spoken by the server
+	helloFragment    = 1024 // bytes of the ca chain in each datagram, below the usual MTU
+	maxHelloRequest  = 512  // larger datagrams are not hello requests
+	maxHelloFragment = 64   // fragments of the largest chain, 64 KiB
 )
 
-func StartHelloServer(socket net.PacketConn, caPath string) (error) {
+// discoveryGroup is the multicast group servers join to be discovered, on
+// the hello port.
+var discoveryGroup = net.IPv4(239, 255, 84, 21)
+
+// helloAnswer answers the hello requests, the fragments of the ca chain are
+// built once when the server starts.
+type helloAnswer struct {
+	state     *types.ServerState
+	guard     *helloGuard
+	fragments []*pb.HelloFragment
+}
+
+func StartHelloServer(socket net.PacketConn, state *types.ServerState) error {
 	log.Println("Starting Hello Server")
 
-	// read ca certificate
-	cacert, err := ioutil.ReadFile(caPath)
-	if err!= nil { return err }
+	// read the ca chain, clients pin its root and check the intermediates
+	// against it
+	data, err := ioutil.ReadFile(state.GetCaPath())
+	if err != nil {
+		return err
+	}
+	chain, err := utils.ParseCAChain(data)
+	if err != nil {
+		return fmt.Errorf("invalid ca chain in %v: %v", state.GetCaPath(), err)
+	}
 
-	log.Println("CA certificate loaded")
+	// clients pin this on first contact, operators can share it to check it
+	log.Printf("CA chain loaded, %d certificates, root SHA-256 fingerprint %v", len(chain.Certs), chain.Fingerprint())
 
+	serverName, err := certServerName(state.GetCertPath())
+	if err != nil {
+		return err
+	}
+
+	answer, err := newHelloAnswer(state, chain.PEM(), serverName)
+	if err != nil {
+		return err
+	}
+	if answer.guard, err = newHelloGuard(); err != nil {
+		return err
+	}
+
+	joinDiscoveryGroup(socket)
+
+	buffer := make([]byte, maxHelloRequest+1)
 	for {
-		buffer := make([]byte, 5) // HELLO
 		n, addr, err := socket.ReadFrom(buffer)
-		if err!= nil { return err }
+		if err != nil {
+			return err
+		}
+
+		answer.handle(socket, buffer[:n], addr)
+	}
+}
+
+// handle answers a datagram, or drops it when answering could flood the
+// address it claims to come from.
+func (a *helloAnswer) handle(socket net.PacketConn, datagram []byte, addr net.Addr) {
+	req := &pb.HelloRequest{}
+	if len(datagram) > maxHelloRequest || proto.Unmarshal(datagram, req) != nil {
+		atomic.AddUint64(&helloStats.Malformed, 1)
+		return
+	}
+	if len(datagram) < minHelloRequest {
+		atomic.AddUint64(&helloStats.Unpadded, 1)
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		ip = addr.String()
+	}
+	if !a.guard.allow(ip) {
+		atomic.AddUint64(&helloStats.RateLimited, 1)
+		return
+	}
+
+	// the chain is only sent to an address that proved it receives there
+	verified := false
+	var fragments []*pb.HelloFragment
+	switch {
+	case req.Version == helloVersion && !req.Discover && !a.guard.validCookie(ip, req.Cookie):
+		atomic.AddUint64(&helloStats.Challenged, 1)
+		fragments = []*pb.HelloFragment{{
+			Status:  pb.HelloFragment_COOKIE_REQUIRED,
+			Version: helloVersion,
+			Nonce:   req.Nonce,
+			Cookie:  a.guard.newCookie(ip),
+		}}
+	case req.Version == helloVersion && !req.Discover:
+		verified = true
+		fallthrough
+	default:
+		atomic.AddUint64(&helloStats.Answered, 1)
+		fragments = a.reply(req)
+	}
+
+	for _, fragment := range fragments {
+		data, err := proto.Marshal(fragment)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if !verified && len(data) > len(datagram) {
+			atomic.AddUint64(&helloStats.Oversized, 1)
+			return
+		}
+		if _, err := socket.WriteTo(data, addr); err != nil {
+			log.Println(err) //this should not return an error but if it does, it should not stop the server
+			return
+		}
+	}
+}
 
-		log.Printf("Client connected from %s\n", addr.String())
+// joinDiscoveryGroup makes the socket receive the discovery requests sent to
+// the multicast group on every interface that supports it. Broadcast requests
+// are received anyway, so failing to join is not an error.
+func joinDiscoveryGroup(socket net.PacketConn) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		log.Printf("Could not list the network interfaces: %v", err)
+		return
+	}
+
+	conn := ipv4.NewPacketConn(socket)
+	group := &net.UDPAddr{IP: discoveryGroup}
+	joined := 0
+	for i := range interfaces {
+		ifi := &interfaces[i]
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		if err := conn.JoinGroup(ifi, group); err != nil {
+			log.Printf("Could not join the discovery group on %v: %v", ifi.Name, err)
+			continue
+		}
+		joined++
+	}
+
+	log.Printf("Listening for discovery on %d interfaces", joined)
+}
+
+// newHelloAnswer splits a PEM ca chain in fragments.
+func newHelloAnswer(state *types.ServerState, chain []byte, serverName string) (*helloAnswer, error) {
+	count := (len(chain) + helloFragment - 1) / helloFragment
+	if count > maxHelloFragment {
+		return nil, errors.New("ca chain is too large for the hello protocol")
+	}
+
+	port := uint32(state.GetPort())
+	sum := sha256.Sum256(chain)
+	answer := &helloAnswer{state: state}
+	for i := 0; i < count; i++ {
+		end := (i + 1) * helloFragment
+		if end > len(chain) {
+			end = len(chain)
+		}
+		data := chain[i*helloFragment : end]
+
+		answer.fragments = append(answer.fragments, &pb.HelloFragment{
+			Status:      pb.HelloFragment_OK,
+			Version:     helloVersion,
+			ServerName:  serverName,
+			Port:        port,
+			Index:       uint32(i),
+			Count:       uint32(count),
+			Data:        data,
+			Checksum:    crc32.ChecksumIEEE(data),
+			ChainSha256: sum[:],
+		})
+	}
+
+	return answer, nil
+}
+
+// reply returns the fragments asked by a request with its nonce.
+func (a *helloAnswer) reply(req *pb.HelloRequest) []*pb.HelloFragment {
+	if req.Version != helloVersion {
+		return []*pb.HelloFragment{{Status: pb.HelloFragment_UNSUPPORTED_VERSION, Version: helloVersion, Nonce: req.Nonce}}
+	}
 
-		if strings.Contains(string(buffer[:n]), "HELLO") {
-            log.Println("Sending CA certificate")
-            _, err = socket.WriteTo([]byte(string(cacert)), addr)
-            if err!= nil { 
-				log.Println(err) //this should not return an error but if it does, it should not stop the server
-			}
+	if req.Discover {
+		return []*pb.HelloFragment{a.discovery(req)}
+	}
+
+	wanted := req.Fragments
+	if len(wanted) == 0 {
+		for i := range a.fragments {
+			wanted = append(wanted, uint32(i))
+		}
+	}
+
+	var fragments []*pb.HelloFragment
+	for _, i := range wanted {
+		if int(i) >= len(a.fragments) {
+			continue
 		}
+		fragment := proto.Clone(a.fragments[i]).(*pb.HelloFragment)
+		fragment.Nonce = req.Nonce
+		fragments = append(fragments, fragment)
+	}
+
+	return fragments
+}
+
+// discovery describes the server to a client looking for servers. It goes
+// to an unverified address, so it leaves out the chain and the name of the
+// certificate, which has no length limit, and stays smaller than a request
+// as long as the name of the server is as short as the -name flag allows.
+func (a *helloAnswer) discovery(req *pb.HelloRequest) *pb.HelloFragment {
+	return &pb.HelloFragment{
+		Status:           pb.HelloFragment_OK,
+		Version:          helloVersion,
+		Nonce:            req.Nonce,
+		Port:             a.fragments[0].Port,
+		Name:             a.state.GetName(),
+		Clients:          uint32(a.state.GetCurrentClients()),
+		MaxClients:       uint32(a.state.GetMaxClients()),
+		PasswordRequired: a.state.GetServerPassword() != "",
+	}
+}
+
+// certServerName returns the name clients verify in the server certificate,
+// its first DNS name or its common name.
+func certServerName(certPath string) (string, error) {
+	data, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", errors.New("no certificate found in " + certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0], nil
 	}
-}
\ No newline at end of file
+	return cert.Subject.CommonName, nil
+}
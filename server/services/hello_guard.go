@@ -0,0 +1,129 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	minHelloRequest  = 256             // smaller requests are dropped, answers to unverified addresses are never larger
+	helloBurst       = 10              // requests a source can send at once
+	helloRate        = 2               // requests per second a source can send after the burst
+	helloCookieEpoch = time.Minute     // cookies are valid for this epoch and the next one
+	helloSweep       = 5 * time.Minute // how often sources that stopped sending are forgotten
+	helloCookieSize  = 16
+)
+
+// HelloStats are counters of the hello server shown to operators.
+type HelloStats struct {
+	Answered    uint64 // requests answered in full
+	Challenged  uint64 // chain requests answered with a cookie
+	Malformed   uint64 // dropped, not hello requests
+	Unpadded    uint64 // dropped, smaller than minHelloRequest
+	RateLimited uint64 // dropped, the source sent too many
+	Oversized   uint64 // answers not sent, larger than the request of an unverified address
+}
+
+var helloStats HelloStats
+
+func (s *HelloStats) load() HelloStats {
+	return HelloStats{
+		Answered:    atomic.LoadUint64(&s.Answered),
+		Challenged:  atomic.LoadUint64(&s.Challenged),
+		Malformed:   atomic.LoadUint64(&s.Malformed),
+		Unpadded:    atomic.LoadUint64(&s.Unpadded),
+		RateLimited: atomic.LoadUint64(&s.RateLimited),
+		Oversized:   atomic.LoadUint64(&s.Oversized),
+	}
+}
+
+// helloGuard keeps the hello server from being used to flood others with
+// answers to requests with a spoofed source: it limits the requests of each
+// source and gives cookies that prove a client receives at its address.
+type helloGuard struct {
+	mu        sync.Mutex
+	secret    []byte
+	sources   map[string]*helloSource // ip: requests
+	lastSweep time.Time
+}
+
+// helloSource is a token bucket of the requests of an address.
+type helloSource struct {
+	tokens float64
+	last   time.Time
+}
+
+func newHelloGuard() (*helloGuard, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &helloGuard{
+		secret:    secret,
+		sources:   make(map[string]*helloSource),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// allow takes a request from the budget of a source, it reports false when
+// the source sent too many.
+func (g *helloGuard) allow(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.lastSweep) >= helloSweep {
+		g.lastSweep = now
+		for key, source := range g.sources {
+			if now.Sub(source.last).Seconds()*helloRate >= helloBurst {
+				delete(g.sources, key)
+			}
+		}
+	}
+
+	source, ok := g.sources[ip]
+	if !ok {
+		source = &helloSource{tokens: helloBurst, last: now}
+		g.sources[ip] = source
+	}
+
+	source.tokens = math.Min(helloBurst, source.tokens+now.Sub(source.last).Seconds()*helloRate)
+	source.last = now
+	if source.tokens < 1 {
+		return false
+	}
+
+	source.tokens--
+	return true
+}
+
+// cookie is the cookie of an address for an epoch, the server can check it
+// without remembering it.
+func (g *helloGuard) cookie(ip string, epoch int64) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	binary.Write(mac, binary.BigEndian, epoch)
+	mac.Write([]byte(ip))
+	return mac.Sum(nil)[:helloCookieSize]
+}
+
+func (g *helloGuard) newCookie(ip string) []byte {
+	return g.cookie(ip, time.Now().Unix()/int64(helloCookieEpoch.Seconds()))
+}
+
+// validCookie checks a cookie given to the address in this epoch or the
+// previous one.
+func (g *helloGuard) validCookie(ip string, cookie []byte) bool {
+	if len(cookie) != helloCookieSize {
+		return false
+	}
+
+	epoch := time.Now().Unix() / int64(helloCookieEpoch.Seconds())
+	return hmac.Equal(cookie, g.cookie(ip, epoch)) || hmac.Equal(cookie, g.cookie(ip, epoch-1))
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash/crc32"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/storage"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+func newTestAnswer(t *testing.T, ca []byte) *helloAnswer {
+	t.Helper()
+
+	state := types.NewServerState(storage.NewMemoryStorage())
+	state.SetPort(8421)
+	answer, err := newHelloAnswer(state, ca, "chat.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return answer
+}
+
+func TestHelloAnswerFragments(t *testing.T) {
+	ca := bytes.Repeat([]byte("0123456789"), 250) // 2500 bytes, 3 fragments
+	answer := newTestAnswer(t, ca)
+
+	fragments := answer.reply(&pb.HelloRequest{Version: helloVersion, Nonce: []byte("nonce")})
+	if len(fragments) != 3 {
+		t.Fatalf("got %d fragments, want 3", len(fragments))
+	}
+
+	var joined []byte
+	for i, fragment := range fragments {
+		if fragment.Index != uint32(i) || fragment.Count != 3 || !bytes.Equal(fragment.Nonce, []byte("nonce")) {
+			t.Fatalf("fragment %d: got index %d of %d, nonce %q", i, fragment.Index, fragment.Count, fragment.Nonce)
+		}
+		if fragment.Checksum != crc32.ChecksumIEEE(fragment.Data) || fragment.Port != 8421 {
+			t.Fatalf("fragment %d: bad checksum or port", i)
+		}
+		joined = append(joined, fragment.Data...)
+	}
+
+	sum := sha256.Sum256(ca)
+	if !bytes.Equal(joined, ca) || !bytes.Equal(fragments[0].ChainSha256, sum[:]) {
+		t.Fatal("the fragments do not join into the ca")
+	}
+}
+
+func TestHelloAnswerSelectedFragments(t *testing.T) {
+	answer := newTestAnswer(t, bytes.Repeat([]byte("x"), 2500))
+
+	fragments := answer.reply(&pb.HelloRequest{Version: helloVersion, Fragments: []uint32{2, 7, 0}})
+	if len(fragments) != 2 || fragments[0].Index != 2 || fragments[1].Index != 0 {
+		t.Fatalf("got %d fragments, want 2 and 0", len(fragments))
+	}
+
+	// the nonce of a reply does not leak into the prepared fragments
+	answer.reply(&pb.HelloRequest{Version: helloVersion, Nonce: []byte("a")})
+	if answer.fragments[0].Nonce != nil {
+		t.Fatal("a reply changed the prepared fragments")
+	}
+}
+
+func TestHelloAnswerVersionMismatch(t *testing.T) {
+	answer := newTestAnswer(t, []byte("ca"))
+
+	fragments := answer.reply(&pb.HelloRequest{Version: helloVersion + 1, Nonce: []byte("n")})
+	if len(fragments) != 1 || fragments[0].Status != pb.HelloFragment_UNSUPPORTED_VERSION || fragments[0].Data != nil {
+		t.Fatalf("got %v, want one UNSUPPORTED_VERSION answer without data", fragments)
+	}
+}
+
+func TestHelloAnswerTooLarge(t *testing.T) {
+	state := types.NewServerState(storage.NewMemoryStorage())
+	if _, err := newHelloAnswer(state, make([]byte, helloFragment*maxHelloFragment+1), "x"); err == nil {
+		t.Fatal("split a ca larger than the protocol allows")
+	}
+}
+
+// packetRecorder is a socket keeping the datagrams written to it.
+type packetRecorder struct {
+	net.PacketConn
+	sent [][]byte
+}
+
+func (p *packetRecorder) WriteTo(data []byte, addr net.Addr) (int, error) {
+	p.sent = append(p.sent, data)
+	return len(data), nil
+}
+
+func TestHelloDiscoveryLongServerName(t *testing.T) {
+	state := types.NewServerState(storage.NewMemoryStorage())
+	state.SetPort(8421)
+	state.SetName(strings.Repeat("n", 64))
+	state.SetMaxClients(1000000)
+
+	// a certificate name can be as long as a DNS name gets
+	longName := strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 61)
+	answer, err := newHelloAnswer(state, []byte("chain"), longName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.guard, err = newHelloGuard(); err != nil {
+		t.Fatal(err)
+	}
+
+	// padded to the minimum like the client does
+	probe := &pb.HelloRequest{Version: helloVersion, Nonce: make([]byte, 16), Discover: true}
+	for size := proto.Size(probe); size < minHelloRequest; size = proto.Size(probe) {
+		probe.Padding = append(probe.Padding, make([]byte, minHelloRequest-size)...)
+	}
+	req, err := proto.Marshal(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := &packetRecorder{}
+	answer.handle(socket, req, &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 4000})
+	if len(socket.sent) != 1 {
+		t.Fatalf("got %d answers to the discovery, want 1", len(socket.sent))
+	}
+	if len(socket.sent[0]) > len(req) {
+		t.Fatalf("answer of %d bytes to a request of %d", len(socket.sent[0]), len(req))
+	}
+
+	reply := &pb.HelloFragment{}
+	if err := proto.Unmarshal(socket.sent[0], reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != state.GetName() || reply.Port != 8421 || reply.ServerName != "" || reply.Data != nil {
+		t.Fatalf("got %v", reply)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// subscriberBuffer is the number of items queued for a subscriber before new
+// ones are dropped for it, so a slow client never blocks the others.
+const subscriberBuffer = 64
+
+// subscriber is a stream registered in a hub.
+type subscriber struct {
+	user     *types.User
+	username string
+	ch       chan interface{}
+	dropped  int // items dropped since the last successful delivery, guarded by the hub
+}
+
+// hub fans out every published item to its subscribers.
+type hub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+func newHub() *hub {
+	return &hub{
+		subs: make(map[*subscriber]struct{}),
+	}
+}
+
+func (h *hub) subscribe(user *types.User) *subscriber {
+	sub := &subscriber{
+		user:     user,
+		username: user.GetUsername(),
+		ch:       make(chan interface{}, subscriberBuffer),
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+// publish delivers an item to every subscriber accepted by filter, a nil
+// filter accepts all of them. Subscribers with a full buffer miss the item.
+// It returns the number of subscribers the item was delivered to.
+func (h *hub) publish(item interface{}, filter func(sub *subscriber) bool) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delivered := 0
+	for sub := range h.subs {
+		if filter != nil && !filter(sub) {
+			continue
+		}
+
+		select {
+		case sub.ch <- item:
+			sub.dropped = 0
+			delivered++
+		default:
+			if sub.dropped == 0 {
+				log.Printf("Subscriber %v is too slow, dropping items", sub.username)
+			}
+			sub.dropped++
+		}
+	}
+
+	return delivered
+}
+
+// count returns the number of subscribers.
+func (h *hub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	backoffBase    = time.Second      // wait after the first failed login
+	backoffMax     = 30 * time.Second // longest wait between two logins
+	ipFailureRatio = 4                // an address is locked after this many times the failures of an account
+	failureMemory  = 15 * time.Minute // failures are kept at least this long after the last one, so the backoff keeps growing
+)
+
+// loginFailures are the failed logins of a username or an address.
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// loginGuard tracks failed logins by username and by address to slow down and
+// lock out password guessing.
+type loginGuard struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures // user:name or ip:address
+}
+
+var logins = &loginGuard{
+	failures: make(map[string]*loginFailures),
+}
+
+// Lockout is a locked username or address shown to the operators.
+type Lockout struct {
+	Key      string // user:name or ip:address
+	Failures int
+	Until    time.Time
+}
+
+// userKey is the key of a username, exact like the usernames themselves so
+// failures against a case variant do not lock the account out.
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// check returns if a login for the username from the address is locked out,
+// and otherwise how long it has to wait because of the previous failures.
+func (g *loginGuard) check(username string, ip string) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	// an address is shared by many accounts, it backs off as slowly as it locks
+	ratios := map[string]int{userKey(username): 1, ipKey(ip): ipFailureRatio}
+	for key, ratio := range ratios {
+		f, ok := g.failures[key]
+		if !ok {
+			continue
+		}
+		if now.Before(f.lockedUntil) {
+			return true, 0
+		}
+		if w := f.last.Add(backoff(f.count / ratio)).Sub(now); w > wait {
+			wait = w
+		}
+	}
+
+	return false, wait
+}
+
+// fail records a failed login and locks the username or the address when
+// they reach the limit of the server.
+func (g *loginGuard) fail(username string, ip string) {
+	after, duration := authState.GetLockout()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.sweep(now, duration)
+
+	limits := map[string]int{userKey(username): after, ipKey(ip): after * ipFailureRatio}
+	for key, limit := range limits {
+		f, ok := g.failures[key]
+		if !ok {
+			f = &loginFailures{}
+			g.failures[key] = f
+		}
+		f.count++
+		f.last = now
+
+		if limit > 0 && f.count >= limit && !now.Before(f.lockedUntil) {
+			f.lockedUntil = now.Add(duration)
+			log.Printf("Audit: %v locked until %v after %d failed logins", key, f.lockedUntil.Format(time.RFC3339), f.count)
+		}
+	}
+
+	log.Printf("Audit: failed login for %v from %v (%d failures)", username, ip, g.failures[userKey(username)].count)
+}
+
+// succeed forgets the failures of a username after a login. The failures of
+// the address are kept, logging into an account of its own must not let an
+// address go on guessing the passwords of the others.
+func (g *loginGuard) succeed(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.failures, userKey(username))
+}
+
+// sweep forgets the failures that are not locked and older than a lockout, or
+// than failureMemory when the lockout is shorter. The caller must hold the
+// lock.
+func (g *loginGuard) sweep(now time.Time, duration time.Duration) {
+	if duration < failureMemory {
+		duration = failureMemory
+	}
+	for key, f := range g.failures {
+		if now.Sub(f.last) > duration && now.After(f.lockedUntil) {
+			delete(g.failures, key)
+		}
+	}
+}
+
+// backoff is the time to wait after count failed logins, it doubles with
+// every failure.
+func backoff(count int) time.Duration {
+	if count <= 0 {
+		return 0
+	}
+
+	wait := backoffBase
+	for i := 1; i < count && wait < backoffMax; i++ {
+		wait *= 2
+	}
+	if wait > backoffMax {
+		wait = backoffMax
+	}
+	return wait
+}
+
+// Lockouts returns the usernames and addresses that are locked out.
+func Lockouts() []Lockout {
+	logins.mu.Lock()
+	defer logins.mu.Unlock()
+
+	now := time.Now()
+	var lockouts []Lockout
+	for key, f := range logins.failures {
+		if now.Before(f.lockedUntil) {
+			lockouts = append(lockouts, Lockout{Key: key, Failures: f.count, Until: f.lockedUntil})
+		}
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool {
+		return lockouts[i].Key < lockouts[j].Key
+	})
+	return lockouts
+}
+
+// ClearLockout removes the lockout and failures of a username or an address,
+// by is who cleared it. It reports if there was anything to clear.
+func ClearLockout(target string, by string) bool {
+	logins.mu.Lock()
+	defer logins.mu.Unlock()
+
+	cleared := false
+	for _, key := range []string{target, userKey(target), ipKey(target)} {
+		if _, ok := logins.failures[key]; ok {
+			delete(logins.failures, key)
+			log.Printf("Audit: lockout of %v cleared by %v", key, by)
+			cleared = true
+		}
+	}
+
+	return cleared
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/corrreia/chatroom-grpc/server/storage"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// newTestGuard returns an empty guard for a server locking accounts after
+// after failures for duration, and a function resetting the server.
+func newTestGuard(after int, duration time.Duration) (*loginGuard, func()) {
+	authState = types.NewServerState(storage.NewMemoryStorage())
+	authState.SetLockout(after, duration)
+
+	return &loginGuard{failures: make(map[string]*loginFailures)}, func() { authState = nil }
+}
+
+func TestBackoff(t *testing.T) {
+	for count, want := range map[int]time.Duration{
+		0:   0,
+		1:   time.Second,
+		2:   2 * time.Second,
+		3:   4 * time.Second,
+		5:   16 * time.Second,
+		6:   backoffMax,
+		100: backoffMax,
+	} {
+		if got := backoff(count); got != want {
+			t.Errorf("backoff(%d): got %v, want %v", count, got, want)
+		}
+	}
+}
+
+func TestLoginGuardBackoff(t *testing.T) {
+	g, reset := newTestGuard(5, time.Minute)
+	defer reset()
+
+	if locked, wait := g.check("alice", "10.0.0.1"); locked || wait != 0 {
+		t.Fatalf("got %v, %v before any failure", locked, wait)
+	}
+
+	g.fail("alice", "10.0.0.1")
+	g.fail("alice", "10.0.0.1")
+	locked, wait := g.check("alice", "10.0.0.2")
+	if locked || wait <= time.Second || wait > 2*time.Second {
+		t.Fatalf("got %v, %v, want a wait of about 2s", locked, wait)
+	}
+
+	// the address has not failed enough to back off for other accounts
+	if locked, wait := g.check("bob", "10.0.0.1"); locked || wait != 0 {
+		t.Fatalf("got %v, %v for another account", locked, wait)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	g, reset := newTestGuard(3, time.Minute)
+	defer reset()
+
+	for i := 0; i < 3; i++ {
+		g.fail("alice", "10.0.0.1")
+	}
+	if locked, _ := g.check("alice", "10.0.0.2"); !locked {
+		t.Fatal("expected the account to be locked")
+	}
+	if locked, _ := g.check("Alice", "10.0.0.2"); locked {
+		t.Fatal("a case variant of the username is locked")
+	}
+
+	g.failures[userKey("alice")].lockedUntil = time.Now().Add(-time.Second)
+	if locked, _ := g.check("alice", "10.0.0.2"); locked {
+		t.Fatal("the account is still locked after the lockout")
+	}
+}
+
+func TestLoginGuardAddressLockout(t *testing.T) {
+	g, reset := newTestGuard(3, time.Minute)
+	defer reset()
+
+	// spraying one password over many accounts locks the address
+	for i := 0; i < 3*ipFailureRatio; i++ {
+		g.fail(fmt.Sprintf("user%d", i), "10.0.0.1")
+	}
+	if locked, _ := g.check("someone", "10.0.0.1"); !locked {
+		t.Fatal("expected the address to be locked")
+	}
+	if locked, _ := g.check("someone", "10.0.0.2"); locked {
+		t.Fatal("another address is locked")
+	}
+}
+
+func TestLoginGuardSucceed(t *testing.T) {
+	g, reset := newTestGuard(5, time.Minute)
+	defer reset()
+
+	for i := 0; i < 2*ipFailureRatio; i++ {
+		g.fail(fmt.Sprintf("user%d", i), "10.0.0.1")
+	}
+	g.fail("mallory", "10.0.0.1")
+
+	// logging into its own account forgets the failures of the account only
+	g.succeed("mallory")
+	if _, ok := g.failures[userKey("mallory")]; ok {
+		t.Fatal("the failures of the account are kept")
+	}
+	if f := g.failures[ipKey("10.0.0.1")]; f == nil || f.count != 2*ipFailureRatio+1 {
+		t.Fatalf("got %+v, want the failures of the address kept", f)
+	}
+	if _, wait := g.check("victim", "10.0.0.1"); wait == 0 {
+		t.Fatal("the address no longer backs off")
+	}
+}
+
+func TestLoginGuardKeepsFailuresWithoutLockout(t *testing.T) {
+	g, reset := newTestGuard(0, 0)
+	defer reset()
+
+	for i := 1; i <= 4; i++ {
+		g.fail("alice", "10.0.0.1")
+		// pretend the wait is over before the next attempt
+		g.failures[userKey("alice")].last = time.Now().Add(-backoffMax)
+		if f := g.failures[userKey("alice")]; f.count != i {
+			t.Fatalf("got %d failures, want %d", f.count, i)
+		}
+	}
+	if locked, _ := g.check("alice", "10.0.0.1"); locked {
+		t.Fatal("locked with lockouts off")
+	}
+
+	// failures are forgotten once failureMemory passed without a new one
+	g.failures[userKey("alice")].last = time.Now().Add(-failureMemory - time.Second)
+	g.fail("bob", "10.0.0.2")
+	if _, ok := g.failures[userKey("alice")]; ok {
+		t.Fatal("old failures are kept")
+	}
+}
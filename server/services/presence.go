@@ -0,0 +1,109 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// awayCheckInterval is how often the connected users are checked for idleness
+const awayCheckInterval = 15 * time.Second
+
+var presenceHub = newHub()
+
+func (s *communicationServer) SubscribePresence(req *pb.SubRequest, stream pb.PresenceService_SubscribePresenceServer) error {
+	user, ok := interceptors.UserFromContext(stream.Context())
+	if !ok {
+		return status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	// the stream is closed when the session logs out
+	session, _ := interceptors.SessionFromContext(stream.Context())
+	ctx, done := sessionStreams.register(stream.Context(), session.GetId())
+	defer done()
+
+	// subscribe before the snapshot so no change is missed in between, the
+	// client applies events on top of the snapshot so repeating one is harmless
+	sub := presenceHub.subscribe(user)
+	defer presenceHub.unsubscribe(sub)
+
+	var roster []*pb.RosterEntry
+	for _, u := range communicationState.GetConnectedUserList() {
+		roster = append(roster, rosterEntry(u))
+	}
+	snapshot := &pb.PresenceEvent{Type: pb.PresenceEvent_SNAPSHOT, Roster: roster, Timestamp: timestamp(time.Now())}
+	if err := stream.Send(snapshot); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-sub.ch:
+			if err := stream.Send(item.(*pb.PresenceEvent)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func rosterEntry(user *types.User) *pb.RosterEntry {
+	return &pb.RosterEntry{
+		Username: user.GetUsername(),
+		Away:     user.IsAway(),
+		Admin:    user.IsAdmin(),
+	}
+}
+
+// timestamp converts a time to unix milliseconds as sent to the clients.
+func timestamp(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// publishPresence tells every presence stream about a change of a user.
+func publishPresence(user *types.User, event pb.PresenceEvent_Type) {
+	presenceHub.publish(&pb.PresenceEvent{
+		Type:      event,
+		User:      rosterEntry(user),
+		Timestamp: timestamp(time.Now()),
+	}, nil)
+}
+
+// markActive records an action of a user, announcing it is back if it was away.
+func markActive(user *types.User) {
+	if user.Touch() {
+		log.Printf("User %v is back", user.GetUsername())
+		publishPresence(user, pb.PresenceEvent_BACK)
+	}
+}
+
+// watchIdle marks the connected users that did nothing for the away timeout
+// of the server as away. It runs for the life of the server.
+func watchIdle() {
+	ticker := time.NewTicker(awayCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		timeout := communicationState.GetAwayTimeout()
+		if timeout <= 0 {
+			continue
+		}
+
+		for _, user := range communicationState.GetConnectedUserList() {
+			if user.IsAway() || time.Since(user.GetLastActive()) < timeout {
+				continue
+			}
+
+			user.SetAway(true)
+			log.Printf("User %v is away", user.GetUsername())
+			publishPresence(user, pb.PresenceEvent_AWAY)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/corrreia/chatroom-grpc/proto"
+	"github.com/corrreia/chatroom-grpc/server/interceptors"
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+const maxTopicLength = 200 // maximum number of characters in a room topic
+
+// roomNamePattern is checked after lowercasing the name.
+var roomNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+var (
+	errRoomNotFound = errors.New("room not found")
+	errNotAMember   = errors.New("not a member of the room")
+)
+
+// roomName normalizes a room name, an empty one is the default room.
+func roomName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return types.DefaultRoom
+	}
+	return name
+}
+
+// memberRoom finds a room a user can send messages to.
+func memberRoom(user *types.User, name string) (*types.Room, error) {
+	room := communicationState.GetRoom(roomName(name))
+	if room == nil {
+		return nil, errRoomNotFound
+	}
+
+	if !room.IsMember(user.GetId()) {
+		return nil, errNotAMember
+	}
+
+	return room, nil
+}
+
+// inRoom returns a hub filter that accepts the current members of a room.
+func inRoom(room *types.Room) func(sub *subscriber) bool {
+	return func(sub *subscriber) bool {
+		return room.IsMember(sub.user.GetId())
+	}
+}
+
+// visibleTo returns a history filter that keeps the messages of the rooms a
+// user is currently in and its direct messages.
+func visibleTo(user *types.User) func(message types.Message) bool {
+	username := user.GetUsername()
+	return func(message types.Message) bool {
+		if message.Recipient != "" {
+			return message.Sender == username || message.Recipient == username
+		}
+
+		room := communicationState.GetRoom(message.Room)
+		return room != nil && room.IsMember(user.GetId())
+	}
+}
+
+func (s *communicationServer) CreateRoom(ctx context.Context, req *pb.RoomRequest) (*pb.RoomResponse, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	name := roomName(req.Room)
+	topic := cleanText(req.Topic)
+	if !roomNamePattern.MatchString(name) || len([]rune(topic)) > maxTopicLength {
+		return &pb.RoomResponse{Status: pb.RoomResponse_INVALID_NAME}, nil
+	}
+
+	if communicationState.GetRoom(name) != nil {
+		return &pb.RoomResponse{Status: pb.RoomResponse_ROOM_EXISTS}, nil
+	}
+
+	// CreateRoom checks the name again in case of a concurrent create
+	if _, err := communicationState.CreateRoom(name, topic, user); err != nil {
+		log.Printf("Could not create room %v: %v", name, err)
+		return &pb.RoomResponse{Status: pb.RoomResponse_ROOM_EXISTS}, nil
+	}
+	log.Printf("User %v created room %v", user.GetUsername(), name)
+
+	return &pb.RoomResponse{Status: pb.RoomResponse_OK}, nil
+}
+
+func (s *communicationServer) ListRooms(ctx context.Context, req *pb.ListRoomsRequest) (*pb.ListRoomsResponse, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	var rooms []*pb.RoomInfo
+	for _, room := range communicationState.GetRoomList() {
+		rooms = append(rooms, &pb.RoomInfo{
+			Name:    room.GetName(),
+			Topic:   room.GetTopic(),
+			Members: uint32(room.GetMemberCount()),
+			Joined:  room.IsMember(user.GetId()),
+		})
+	}
+
+	return &pb.ListRoomsResponse{Rooms: rooms}, nil
+}
+
+func (s *communicationServer) JoinRoom(ctx context.Context, req *pb.RoomRequest) (*pb.RoomResponse, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	room := communicationState.GetRoom(roomName(req.Room))
+	if room == nil {
+		return &pb.RoomResponse{Status: pb.RoomResponse_ROOM_NOT_FOUND}, nil
+	}
+
+	if room.IsMember(user.GetId()) {
+		return &pb.RoomResponse{Status: pb.RoomResponse_ALREADY_A_MEMBER}, nil
+	}
+
+	if err := communicationState.JoinRoom(room, user); err != nil {
+		log.Printf("User %v could not join room %v: %v", user.GetUsername(), room.GetName(), err)
+		return &pb.RoomResponse{Status: pb.RoomResponse_ERROR}, nil
+	}
+	log.Printf("User %v joined room %v", user.GetUsername(), room.GetName())
+
+	return &pb.RoomResponse{Status: pb.RoomResponse_OK}, nil
+}
+
+func (s *communicationServer) LeaveRoom(ctx context.Context, req *pb.RoomRequest) (*pb.RoomResponse, error) {
+	user, ok := interceptors.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+
+	room, err := memberRoom(user, req.Room)
+	if err == errRoomNotFound {
+		return &pb.RoomResponse{Status: pb.RoomResponse_ROOM_NOT_FOUND}, nil
+	}
+	if err == errNotAMember {
+		return &pb.RoomResponse{Status: pb.RoomResponse_NOT_A_MEMBER}, nil
+	}
+
+	if err := communicationState.LeaveRoom(room, user); err != nil {
+		log.Printf("User %v could not leave room %v: %v", user.GetUsername(), room.GetName(), err)
+		return &pb.RoomResponse{Status: pb.RoomResponse_ERROR}, nil
+	}
+	log.Printf("User %v left room %v", user.GetUsername(), room.GetName())
+
+	return &pb.RoomResponse{Status: pb.RoomResponse_OK}, nil
+}
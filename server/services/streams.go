@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// detachGrace is how long a session can stay without streams after they
+// closed on the client side, before it is ended as disconnected
+const detachGrace = 30 * time.Second
+
+// streamRegistry keeps track of the server streams opened by each session
+// (identified by its id, the token changes on refresh) so they can be closed
+// when the session ends.
+type streamRegistry struct {
+	mu      sync.Mutex
+	next    int
+	streams map[string]map[int]context.CancelFunc // session id: stream id: cancel
+}
+
+var sessionStreams = newStreamRegistry()
+
+func newStreamRegistry() *streamRegistry {
+	return &streamRegistry{
+		streams: make(map[string]map[int]context.CancelFunc),
+	}
+}
+
+// register returns a context derived from the stream context that is cancelled
+// when the session is closed, and a function to call once the stream is done.
+// When the last stream of a session is done the session is ended after
+// detachGrace, unless it opened a stream again.
+func (r *streamRegistry) register(ctx context.Context, session string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	id := r.next
+	r.next++
+	if r.streams[session] == nil {
+		r.streams[session] = make(map[int]context.CancelFunc)
+	}
+	r.streams[session][id] = cancel
+	r.mu.Unlock()
+
+	done := func() {
+		r.mu.Lock()
+		delete(r.streams[session], id)
+		last := len(r.streams[session]) == 0
+		if last {
+			delete(r.streams, session)
+		}
+		r.mu.Unlock()
+		cancel()
+
+		if last {
+			time.AfterFunc(detachGrace, func() { detachSession(session) })
+		}
+	}
+
+	return ctx, done
+}
+
+// count returns the number of open streams of the session with the given id.
+func (r *streamRegistry) count(session string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.streams[session])
+}
+
+// closeSession cancels every stream opened by the session with the given id.
+func (r *streamRegistry) closeSession(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cancel := range r.streams[session] {
+		cancel()
+	}
+	delete(r.streams, session)
+}
+
+// closeAll cancels every registered stream.
+func (r *streamRegistry) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for session, streams := range r.streams {
+		for _, cancel := range streams {
+			cancel()
+		}
+		delete(r.streams, session)
+	}
+}
+
+// CloseStreams ends every open server stream so the server can stop gracefully.
+func CloseStreams() {
+	sessionStreams.closeAll()
+}
+
+// Stats are counters of the running services shown to operators.
+type Stats struct {
+	MessageStreams      int
+	AnnouncementStreams int
+	PresenceStreams     int
+	Hello               HelloStats
+}
+
+func GetStats() Stats {
+	return Stats{
+		MessageStreams:      chatHub.count(),
+		AnnouncementStreams: announcementHub.count(),
+		PresenceStreams:     presenceHub.count(),
+		Hello:               helloStats.load(),
+	}
+}
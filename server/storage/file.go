@@ -0,0 +1,483 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// files inside the data directory
+const (
+	usersFile    = "users.json"
+	messagesFile = "messages.jsonl"
+)
+
+// fileTail is the number of last messages the file storage keeps in memory,
+// older ones are read from the messages file when asked for
+const fileTail = 10000
+
+// FileStorage keeps the users, rooms and queued direct messages in a JSON
+// file inside a data directory. The whole file is rewritten on every change, which is fine for
+// the number of users of a chat server. Messages are appended to a second
+// file, one JSON object per line, and the last ones are kept in memory to
+// answer most history requests without reading it. Every message is kept
+// unless a history limit is set.
+type FileStorage struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]types.UserRecord // id: user
+	rooms map[string]types.RoomRecord // name: room
+
+	queued map[string][]types.Message // user id: direct messages
+
+	historyMu    sync.Mutex
+	historyPath  string
+	history      *os.File
+	historyLimit int             // messages kept in the file, 0 keeps all of them
+	stored       int             // messages in the file
+	trimAt       int             // messages in the file when it is trimmed next
+	tail         []types.Message // last messages, only appended to or replaced
+	lastId       uint64
+}
+
+// usersData is the content of the users file.
+type usersData struct {
+	Version int                `json:"version"`
+	Users   []types.UserRecord `json:"users"`
+	Rooms   []types.RoomRecord `json:"rooms"`
+
+	Queued map[string][]types.Message `json:"queued,omitempty"` // user id: direct messages
+}
+
+// NewFileStorage opens the storage in dir, creating it if needed and
+// migrating files written by older versions. With a historyLimit above 0 only
+// that many last messages are kept, older ones are deleted from the file.
+func NewFileStorage(dir string, historyLimit int) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	f := &FileStorage{
+		path:         filepath.Join(dir, usersFile),
+		users:        make(map[string]types.UserRecord),
+		rooms:        make(map[string]types.RoomRecord),
+		queued:       make(map[string][]types.Message),
+		historyPath:  filepath.Join(dir, messagesFile),
+		historyLimit: historyLimit,
+	}
+
+	data, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Version != currentVersion {
+		log.Printf("Migrating %v from version %d to %d", f.path, data.Version, currentVersion)
+		if err := migrate(data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, user := range data.Users {
+		f.users[user.Id] = user
+	}
+	for _, room := range data.Rooms {
+		f.rooms[room.Name] = room
+	}
+	for id, messages := range data.Queued {
+		f.queued[id] = messages
+	}
+
+	// write the file so it is created or stored in the current version
+	if err := f.write(); err != nil {
+		return nil, err
+	}
+
+	if err := f.openHistory(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// openHistory loads the last messages and opens the messages file to append,
+// deleting the messages past the history limit.
+func (f *FileStorage) openHistory() error {
+	err := f.scanHistory(func(message types.Message) {
+		f.stored++
+		f.lastId = message.Id
+		f.tail = append(f.tail, message)
+		if len(f.tail) >= 2*fileTail {
+			f.tail = append([]types.Message(nil), f.tail[len(f.tail)-fileTail:]...)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(f.tail) > fileTail {
+		f.tail = append([]types.Message(nil), f.tail[len(f.tail)-fileTail:]...)
+	}
+
+	f.history, err = os.OpenFile(f.historyPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	f.trimAt = 2 * f.historyLimit
+	if f.historyLimit > 0 && f.stored > f.historyLimit {
+		return f.trimHistory()
+	}
+	return nil
+}
+
+// trimHistory rewrites the messages file without the messages past the
+// history limit. The new file is written and opened to append before it
+// replaces the old one, so on any error the old file is still the one in
+// use. The caller must hold historyMu.
+func (f *FileStorage) trimHistory() error {
+	drop := f.stored - f.historyLimit
+	if drop <= 0 {
+		return nil
+	}
+
+	tmp := f.historyPath + ".tmp"
+	trimmed, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		trimmed.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	// the kept messages are written again, invalid lines are left out
+	w := bufio.NewWriter(trimmed)
+	kept := 0
+	err = f.scanHistory(func(message types.Message) {
+		if drop > 0 {
+			drop--
+			return
+		}
+		line, err := json.Marshal(message)
+		if err == nil {
+			w.Write(append(line, '\n'))
+			kept++
+		}
+	})
+	if err != nil {
+		return fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := trimmed.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp, f.historyPath); err != nil {
+		return fail(err)
+	}
+
+	log.Printf("Deleted %d messages past the history limit from %v", f.stored-kept, f.historyPath)
+	f.history.Close()
+	f.history = trimmed
+	f.stored = kept
+	f.trimAt = 2 * f.historyLimit
+	if len(f.tail) > f.stored {
+		f.tail = append([]types.Message(nil), f.tail[len(f.tail)-f.stored:]...)
+	}
+	return nil
+}
+
+// scanHistory calls fn with every stored message, oldest first. Lines that
+// cannot be parsed, like one cut by a crash, are skipped.
+func (f *FileStorage) scanHistory(fn func(message types.Message)) error {
+	file, err := os.Open(f.historyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message types.Message
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			log.Printf("Skipping invalid line in %v: %v", f.historyPath, err)
+			continue
+		}
+		if message.Room == "" && message.Recipient == "" { // written before rooms existed
+			message.Room = types.DefaultRoom
+		}
+		fn(message)
+	}
+	return scanner.Err()
+}
+
+// read loads the users file, a missing file is an empty storage.
+func (f *FileStorage) read() (*usersData, error) {
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return &usersData{Version: currentVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &usersData{}
+	if err := json.Unmarshal(content, data); err != nil {
+		return nil, fmt.Errorf("could not parse %v: %v", f.path, err)
+	}
+	return data, nil
+}
+
+// write replaces the users file, going through a temporary file so a crash
+// never leaves it half written. The caller must hold the lock.
+func (f *FileStorage) write() error {
+	data := usersData{Version: currentVersion, Users: f.sortedUsers(), Rooms: f.sortedRooms(), Queued: f.queued}
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func (f *FileStorage) sortedUsers() []types.UserRecord {
+	users := make([]types.UserRecord, 0, len(f.users))
+	for _, user := range f.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users
+}
+
+func (f *FileStorage) sortedRooms() []types.RoomRecord {
+	rooms := make([]types.RoomRecord, 0, len(f.rooms))
+	for _, room := range f.rooms {
+		rooms = append(rooms, room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+	return rooms
+}
+
+func (f *FileStorage) LoadUsers() ([]types.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sortedUsers(), nil
+}
+
+func (f *FileStorage) SaveUser(user types.UserRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, existed := f.users[user.Id]
+	f.users[user.Id] = user
+	if err := f.write(); err != nil {
+		// keep memory in sync with the file
+		if existed {
+			f.users[user.Id] = old
+		} else {
+			delete(f.users, user.Id)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileStorage) DeleteUser(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, existed := f.users[id]
+	if !existed {
+		return nil
+	}
+
+	queued := f.queued[id]
+	delete(f.users, id)
+	delete(f.queued, id)
+	if err := f.write(); err != nil {
+		f.users[id] = old
+		if queued != nil {
+			f.queued[id] = queued
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileStorage) LoadRooms() ([]types.RoomRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sortedRooms(), nil
+}
+
+func (f *FileStorage) SaveRoom(room types.RoomRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, existed := f.rooms[room.Name]
+	f.rooms[room.Name] = room
+	if err := f.write(); err != nil {
+		if existed {
+			f.rooms[room.Name] = old
+		} else {
+			delete(f.rooms, room.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileStorage) QueueMessage(userId string, message types.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old := f.queued[userId]
+	f.queued[userId] = append(old[:len(old):len(old)], message)
+	if err := f.write(); err != nil {
+		f.restoreQueue(userId, old)
+		return err
+	}
+	return nil
+}
+
+func (f *FileStorage) CountQueuedMessages(userId string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.queued[userId]), nil
+}
+
+func (f *FileStorage) TakeQueuedMessages(userId string) ([]types.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	messages, ok := f.queued[userId]
+	if !ok {
+		return nil, nil
+	}
+
+	delete(f.queued, userId)
+	if err := f.write(); err != nil {
+		f.restoreQueue(userId, messages)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// restoreQueue puts back the queue of a user after a failed write.
+func (f *FileStorage) restoreQueue(userId string, messages []types.Message) {
+	if len(messages) == 0 {
+		delete(f.queued, userId)
+	} else {
+		f.queued[userId] = messages
+	}
+}
+
+func (f *FileStorage) AppendMessage(message types.Message) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+
+	if _, err := f.history.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	f.lastId = message.Id
+	f.stored++
+
+	f.tail = append(f.tail, message)
+	if len(f.tail) >= 2*fileTail {
+		f.tail = append([]types.Message(nil), f.tail[len(f.tail)-fileTail:]...)
+	}
+
+	// the file is rewritten once it holds twice the limit, not on every
+	// message, and a failed attempt waits for as many messages again
+	if f.historyLimit > 0 && f.stored >= f.trimAt {
+		if err := f.trimHistory(); err != nil {
+			f.trimAt = f.stored + f.historyLimit
+			log.Printf("Could not delete old messages from %v: %v", f.historyPath, err)
+		}
+	}
+	return nil
+}
+
+// LoadMessages answers from the messages kept in memory, and reads the older
+// ones from the file only when they are not enough.
+func (f *FileStorage) LoadMessages(sinceId uint64, limit int, keep func(message types.Message) bool) ([]types.Message, error) {
+	// appends do not change the messages already in the slice, so it is read
+	// without blocking them
+	f.historyMu.Lock()
+	tail := f.tail
+	complete := len(f.tail) == f.stored
+	lastId := f.lastId
+	f.historyMu.Unlock()
+
+	messages := lastMessages(tail, sinceId, limit, keep)
+	if complete || (limit > 0 && len(messages) == limit) || (len(tail) > 0 && tail[0].Id <= sinceId+1) {
+		return messages, nil
+	}
+
+	// the file is only appended to, or replaced by a trimmed copy, so it
+	// can be read while messages are added
+	messages = nil
+	err := f.scanHistory(func(message types.Message) {
+		if message.Id <= sinceId || message.Id > lastId || (keep != nil && !keep(message)) {
+			return
+		}
+		messages = append(messages, message)
+		// only keep what can still be returned
+		if limit > 0 && len(messages) > 2*limit {
+			messages = append([]types.Message(nil), messages[len(messages)-limit:]...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lastMessages(messages, sinceId, limit, nil), nil
+}
+
+func (f *FileStorage) LastMessageId() (uint64, error) {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+
+	return f.lastId, nil
+}
+
+// Close closes the messages file, users are already written on every change.
+func (f *FileStorage) Close() error {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+
+	return f.history.Close()
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/corrreia/chatroom-grpc/server/types"
+)
+
+// memoryHistory is the number of messages kept by the memory storage
+const memoryHistory = 1000
+
+// MemoryStorage keeps the users and the last messages in memory, they are
+// lost when the server stops.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	users    map[string]types.UserRecord // id: user
+	rooms    map[string]types.RoomRecord // name: room
+	messages []types.Message
+	lastId   uint64
+	queued   map[string][]types.Message // user id: direct messages
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		users: make(map[string]types.UserRecord),
+		rooms:  make(map[string]types.RoomRecord),
+		queued: make(map[string][]types.Message),
+	}
+}
+
+func (m *MemoryStorage) LoadUsers() ([]types.UserRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]types.UserRecord, 0, len(m.users))
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (m *MemoryStorage) SaveUser(user types.UserRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[user.Id] = user
+	return nil
+}
+
+func (m *MemoryStorage) DeleteUser(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, id)
+	delete(m.queued, id)
+	return nil
+}
+
+func (m *MemoryStorage) LoadRooms() ([]types.RoomRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rooms := make([]types.RoomRecord, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (m *MemoryStorage) SaveRoom(room types.RoomRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rooms[room.Name] = room
+	return nil
+}
+
+func (m *MemoryStorage) AppendMessage(message types.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	if len(m.messages) > memoryHistory {
+		m.messages = append([]types.Message(nil), m.messages[len(m.messages)-memoryHistory:]...)
+	}
+	m.lastId = message.Id
+	return nil
+}
+
+func (m *MemoryStorage) LoadMessages(sinceId uint64, limit int, keep func(message types.Message) bool) ([]types.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return lastMessages(m.messages, sinceId, limit, keep), nil
+}
+
+func (m *MemoryStorage) LastMessageId() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lastId, nil
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// lastMessages returns a copy of the last limit messages newer than sinceId
+// accepted by keep, messages must be ordered by id.
+func lastMessages(messages []types.Message, sinceId uint64, limit int, keep func(message types.Message) bool) []types.Message {
+	start := sort.Search(len(messages), func(i int) bool {
+		return messages[i].Id > sinceId
+	})
+
+	var kept []types.Message
+	for i := len(messages) - 1; i >= start; i-- {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+		if keep == nil || keep(messages[i]) {
+			kept = append(kept, messages[i])
+		}
+	}
+
+	// collected newest first
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	return kept
+}
+
+func (m *MemoryStorage) QueueMessage(userId string, message types.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queued[userId] = append(m.queued[userId], message)
+	return nil
+}
+
+func (m *MemoryStorage) CountQueuedMessages(userId string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.queued[userId]), nil
+}
+
+func (m *MemoryStorage) TakeQueuedMessages(userId string) ([]types.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := m.queued[userId]
+	delete(m.queued, userId)
+	return messages, nil
+}
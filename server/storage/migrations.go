@@ -0,0 +1,29 @@
+package storage
+
+import "fmt"
+
+// currentVersion is the version of the files written by this server
+const currentVersion = 1
+
+// migrations upgrade the data of a version to the next one, migrations[v]
+// takes version v to v+1.
+var migrations = []func(data *usersData) error{
+	// version 0 files were written before the version field existed and only
+	// differ in it
+	func(data *usersData) error { return nil },
+}
+
+// migrate upgrades data to the current version.
+func migrate(data *usersData) error {
+	if data.Version > currentVersion {
+		return fmt.Errorf("data version %d is newer than the supported version %d", data.Version, currentVersion)
+	}
+
+	for data.Version < currentVersion {
+		if err := migrations[data.Version](data); err != nil {
+			return fmt.Errorf("could not migrate data from version %d: %v", data.Version, err)
+		}
+		data.Version++
+	}
+	return nil
+}
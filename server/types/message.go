@@ -0,0 +1,15 @@
+package types
+
+import "time"
+
+//Message is a chat message, ids increase with every message sent. Direct
+//messages have a recipient instead of a room
+type Message struct {
+	Id        uint64    `json:"id"`
+	Sender    string    `json:"sender"`
+	Room      string    `json:"room,omitempty"`
+	Recipient string    `json:"recipient,omitempty"` //username
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+	Action    bool      `json:"action,omitempty"` //sent with /me
+}
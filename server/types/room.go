@@ -0,0 +1,129 @@
+package types
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//DefaultRoom is the room every user joins when registering
+const DefaultRoom = "general"
+
+//RoomRecord is the persisted form of a room
+type RoomRecord struct {
+	Name    string    `json:"name"`
+	Topic   string    `json:"topic"`
+	Creator string    `json:"creator"`
+	Created time.Time `json:"created"`
+	Members []string  `json:"members"` //user ids
+}
+
+//room struct, safe for concurrent use. Members are changed through the
+//server state so they are persisted
+type Room struct {
+	mu sync.RWMutex
+
+	name    string
+	topic   string
+	creator string
+	created time.Time
+	members map[string]bool //user ids
+}
+
+func NewRoom(name string, topic string, creator string) *Room {
+	return &Room{
+		name:    name,
+		topic:   topic,
+		creator: creator,
+		created: time.Now(),
+		members: make(map[string]bool),
+	}
+}
+
+//NewRoomFromRecord restores a stored room
+func NewRoomFromRecord(record RoomRecord) *Room {
+	r := &Room{
+		name:    record.Name,
+		topic:   record.Topic,
+		creator: record.Creator,
+		created: record.Created,
+		members: make(map[string]bool, len(record.Members)),
+	}
+	for _, id := range record.Members {
+		r.members[id] = true
+	}
+
+	return r
+}
+
+//Record returns the persisted form of the room
+func (r *Room) Record() RoomRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]string, 0, len(r.members))
+	for id := range r.members {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	return RoomRecord{
+		Name:    r.name,
+		Topic:   r.topic,
+		Creator: r.creator,
+		Created: r.created,
+		Members: members,
+	}
+}
+
+func (r *Room) GetName() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.name
+}
+
+func (r *Room) GetTopic() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.topic
+}
+
+func (r *Room) GetCreator() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.creator
+}
+
+func (r *Room) IsMember(userId string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.members[userId]
+}
+
+func (r *Room) GetMemberCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.members)
+}
+
+//setMember adds or removes a member and reports if it changed anything
+func (r *Room) setMember(userId string, member bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.members[userId] == member {
+		return false
+	}
+
+	if member {
+		r.members[userId] = true
+	} else {
+		delete(r.members, userId)
+	}
+	return true
+}
@@ -1,213 +1,832 @@
 package types
 
-import "errors"
-
-//server state interface
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// server state interface
 type ServerStater interface {
 	//user management
-	AddUser(user User) error
-	RemoveUser(user User) error
+	AddUser(user *User) error
+	RemoveUser(user *User) error
 	IsUserRegistered(user string) bool
+	Load() error
+	SaveUser(user *User) error
+	SetUserAdmin(user *User, admin bool) error
+	SetUserBanned(user *User, banned bool) error
+
+	//rooms
+	CreateRoom(name string, topic string, creator *User) (*Room, error)
+	GetRoom(name string) *Room
+	GetRoomList() []*Room
+	GetUserRooms(user *User) []*Room
+	JoinRoom(room *Room, user *User) error
+	LeaveRoom(room *Room, user *User) error
+
+	//chat history
+	AddMessage(message Message) (Message, error)
+	GetMessages(sinceId uint64, limit int, keep func(message Message) bool) ([]Message, error)
+	GetLastMessageId() uint64
+
+	//direct messages for users that are not connected
+	QueueMessage(user *User, message Message) error
+	TakeQueuedMessages(user *User) ([]Message, error)
+
+	//sessions
+	CreateSession(user *User, address string, client string) (*Session, error)
+	RefreshSession(session *Session) error
+	EndSession(session *Session) error
+	EndUserSessions(user *User) []*Session
+	ExpireSessions(idle time.Duration, alive func(session *Session) bool) []*Session
+	GetSessionByToken(token string) *Session
+	GetSessionById(id string) *Session
+	GetUserSessions(user *User) []*Session
 
 	//user list
-	GetUserList() []User
-	GetConnectedUserList() []User
-	GetBannedUserList() []User
-	GetAdminUserList() []User
+	GetUserList() []*User
+	GetConnectedUserList() []*User
+	GetBannedUserList() []*User
+	GetAdminUserList() []*User
 
 	//user info
-	GetUserByUsername(user string) User
-	GetUserByToken(token string) User
-	GetUserById(id string) User
+	GetUserByUsername(user string) *User
+	GetUserById(id string) *User
 
 	//server info
+	GetName() string
 	GetServerPassword() string
 	GetMaxClients() int
+	GetMaxPerAddress() int
 	GetCaPath() string
+	GetCaKeyPath() string
 	GetCertPath() string
 	GetKeyPath() string
 	GetCurrentClients() int
+	GetAwayTimeout() time.Duration
+	GetSlowMode() time.Duration
+	GetLockout() (int, time.Duration)
+	GetSessionTTL() time.Duration
 
 	//server state
+	SetName(name string) error
 	SetServerPassword(password string) error
 	SetMaxClients(max int) error
+	SetMaxPerAddress(max int) error
+	SetAwayTimeout(timeout time.Duration) error
+	SetSlowMode(interval time.Duration) error
+	SetLockout(after int, duration time.Duration) error
+	SetSessionTTL(ttl time.Duration) error
 	SetCaPath(path string) error
+	SetCaKeyPath(path string) error
 	SetCertPath(path string) error
 	SetKeyPath(path string) error
 }
 
-//server state struct
+// server state struct, safe for concurrent use
 type ServerState struct {
-	Users map[string]User //map of users id: user
+	mu sync.RWMutex
+
+	users     map[string]*User //map of users id: user
+	usernames map[string]*User //map of users username: user
+	rooms     map[string]*Room //map of rooms name: room
+
+	sessions   map[string]*Session //map of sessions token: session
+	sessionIds map[string]*Session //map of sessions id: session
+
+	storage Storage
 
-	serverPass string
-	maxClients int
-	port int
+	messageMu     sync.Mutex //orders the messages, kept apart so storage writes do not block the users
+	lastMessageId uint64
 
-	caPath string
-	certPath string
-	keyPath string
+	queueMu sync.Mutex //keeps the queue size check and the append together
+
+	name            string //shown to clients discovering the server
+	serverPass      string
+	maxClients      int           //0 for no limit
+	maxPerAddress   int           //connected users from one address, 0 for no limit
+	awayTimeout     time.Duration //idle time before a user is away, 0 never
+	slowMode        time.Duration //time between two messages of a user, 0 is off
+	lockoutAfter    int           //failed logins before an account is locked, 0 never
+	lockoutDuration time.Duration
+	sessionTTL      time.Duration //time a session token is valid, refreshing renews it
+	port            int
+
+	caPath    string
+	caKeyPath string //signs the client certificates
+	certPath  string
+	keyPath   string
 }
 
 //user interface is present in user.go
 
-//server state interface implementation
-func (s *ServerState) AddUser(user User) error {
-	if s.IsUserRegistered(user.GetUsername()) {
-		return errors.New("user already registered")
+// server state interface implementation
+// ErrUserExists is returned by AddUser when the username is taken
+var ErrUserExists = errors.New("user already registered")
+
+func (s *ServerState) AddUser(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.usernames[user.GetUsername()]; ok {
+		return ErrUserExists
+	}
+
+	if err := s.storage.SaveUser(user.Record()); err != nil {
+		return err
 	}
 
-	s.Users[user.GetId()] = user
+	s.users[user.GetId()] = user
+	s.usernames[user.GetUsername()] = user
 	return nil
 }
 
-func (s *ServerState) RemoveUser(user User) error {
-	if !s.IsUserRegistered(user.GetUsername()) {
+func (s *ServerState) RemoveUser(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.GetId()]; !ok {
 		return errors.New("user not registered")
 	}
 
-	delete(s.Users, user.GetId())
+	if err := s.storage.DeleteUser(user.GetId()); err != nil {
+		return err
+	}
+
+	delete(s.users, user.GetId())
+	delete(s.usernames, user.GetUsername())
+	s.endUserSessions(user)
+
+	for _, room := range s.rooms {
+		if room.setMember(user.GetId(), false) {
+			if err := s.storage.SaveRoom(room.Record()); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
-}	
+}
 
 func (s *ServerState) IsUserRegistered(user string) bool {
-	_, ok := s.Users[user]
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.usernames[user]
 	return ok
 }
 
-func (s *ServerState) GetUserList() []User {
-	var users []User
-	for _, user := range s.Users {
-		users = append(users, user)
+// Load adds the users and rooms kept in the storage to the server state and
+// continues the message ids where the stored history ends. The default room is
+// created with every user in it if it does not exist yet
+func (s *ServerState) Load() error {
+	records, err := s.storage.LoadUsers()
+	if err != nil {
+		return err
 	}
 
-	return users
+	roomRecords, err := s.storage.LoadRooms()
+	if err != nil {
+		return err
+	}
+
+	lastMessageId, err := s.storage.LastMessageId()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, record := range records {
+		user := NewUserFromRecord(record)
+		s.users[user.GetId()] = user
+		s.usernames[user.GetUsername()] = user
+	}
+	for _, record := range roomRecords {
+		s.rooms[record.Name] = NewRoomFromRecord(record)
+	}
+
+	if _, ok := s.rooms[DefaultRoom]; !ok {
+		room := NewRoom(DefaultRoom, "", "")
+		for id := range s.users {
+			room.setMember(id, true)
+		}
+		if err := s.storage.SaveRoom(room.Record()); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.rooms[DefaultRoom] = room
+	}
+	s.mu.Unlock()
+
+	s.messageMu.Lock()
+	s.lastMessageId = lastMessageId
+	s.messageMu.Unlock()
+	return nil
 }
 
-func (s *ServerState) GetConnectedUserList() []User {
-	var users []User
-	for _, user := range s.Users {
-		if user.IsConnected() {
-			users = append(users, user)
+// SaveUser persists the current state of a registered user
+func (s *ServerState) SaveUser(user *User) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.users[user.GetId()]; !ok {
+		return errors.New("user not registered")
+	}
+
+	return s.storage.SaveUser(user.Record())
+}
+
+// SetUserAdmin changes the admin flag of a user, it is restored when the user
+// cannot be saved so memory and storage agree
+func (s *ServerState) SetUserAdmin(user *User, admin bool) error {
+	old := user.IsAdmin()
+	user.SetAdmin(admin)
+	if err := s.SaveUser(user); err != nil {
+		user.SetAdmin(old)
+		return err
+	}
+	return nil
+}
+
+// SetUserBanned changes the banned flag of a user, it is restored when the
+// user cannot be saved so memory and storage agree
+func (s *ServerState) SetUserBanned(user *User, banned bool) error {
+	old := user.IsBanned()
+	user.SetBanned(banned)
+	if err := s.SaveUser(user); err != nil {
+		user.SetBanned(old)
+		return err
+	}
+	return nil
+}
+
+// AddMessage gives a message the next id and the current time and stores it
+// in the history
+func (s *ServerState) AddMessage(message Message) (Message, error) {
+	s.messageMu.Lock()
+	defer s.messageMu.Unlock()
+
+	message.Id = s.lastMessageId + 1
+	message.Time = time.Now()
+
+	if err := s.storage.AppendMessage(message); err != nil {
+		return Message{}, err
+	}
+
+	s.lastMessageId = message.Id
+	return message, nil
+}
+
+// GetMessages returns the last limit messages newer than sinceId accepted by
+// keep, oldest first. A nil keep accepts every message
+func (s *ServerState) GetMessages(sinceId uint64, limit int, keep func(message Message) bool) ([]Message, error) {
+	return s.storage.LoadMessages(sinceId, limit, keep)
+}
+
+func (s *ServerState) GetLastMessageId() uint64 {
+	s.messageMu.Lock()
+	defer s.messageMu.Unlock()
+
+	return s.lastMessageId
+}
+
+// MaxQueuedMessages is the number of direct messages kept for a user that is
+// not connected
+const MaxQueuedMessages = 100
+
+var ErrQueueFull = errors.New("message queue is full")
+
+// QueueMessage keeps a direct message until the user connects
+func (s *ServerState) QueueMessage(user *User, message Message) error {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	count, err := s.storage.CountQueuedMessages(user.GetId())
+	if err != nil {
+		return err
+	}
+	if count >= MaxQueuedMessages {
+		return ErrQueueFull
+	}
+
+	return s.storage.QueueMessage(user.GetId(), message)
+}
+
+// TakeQueuedMessages returns the direct messages queued for a user, oldest
+// first, and removes them from the queue
+func (s *ServerState) TakeQueuedMessages(user *User) ([]Message, error) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	return s.storage.TakeQueuedMessages(user.GetId())
+}
+
+// CreateRoom adds a new room with its creator as the first member
+func (s *ServerState) CreateRoom(name string, topic string, creator *User) (*Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[name]; ok {
+		return nil, errors.New("room already exists")
+	}
+
+	room := NewRoom(name, topic, creator.GetUsername())
+	room.setMember(creator.GetId(), true)
+	if err := s.storage.SaveRoom(room.Record()); err != nil {
+		return nil, err
+	}
+
+	s.rooms[name] = room
+	return room, nil
+}
+
+// GetRoom returns nil if the room does not exist
+func (s *ServerState) GetRoom(name string) *Room {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rooms[name]
+}
+
+// filterRooms returns the rooms accepted by keep sorted by name
+func (s *ServerState) filterRooms(keep func(room *Room) bool) []*Room {
+	s.mu.RLock()
+	var rooms []*Room
+	for _, room := range s.rooms {
+		if keep(room) {
+			rooms = append(rooms, room)
 		}
 	}
+	s.mu.RUnlock()
 
-	return users
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].GetName() < rooms[j].GetName()
+	})
+	return rooms
 }
 
-func (s *ServerState) GetBannedUserList() []User {
-	var users []User
-	for _, user := range s.Users {
-		if user.IsBanned() {
-			users = append(users, user)
+func (s *ServerState) GetRoomList() []*Room {
+	return s.filterRooms(func(room *Room) bool { return true })
+}
+
+func (s *ServerState) GetUserRooms(user *User) []*Room {
+	return s.filterRooms(func(room *Room) bool { return room.IsMember(user.GetId()) })
+}
+
+func (s *ServerState) JoinRoom(room *Room, user *User) error {
+	return s.setRoomMember(room, user, true)
+}
+
+func (s *ServerState) LeaveRoom(room *Room, user *User) error {
+	return s.setRoomMember(room, user, false)
+}
+
+// setRoomMember changes the membership of a user and persists the room
+func (s *ServerState) setRoomMember(room *Room, user *User, member bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.GetId()]; !ok {
+		return errors.New("user not registered")
+	}
+
+	if !room.setMember(user.GetId(), member) {
+		if member {
+			return errors.New("already a member")
 		}
+		return errors.New("not a member")
 	}
 
-	return users
+	if err := s.storage.SaveRoom(room.Record()); err != nil {
+		room.setMember(user.GetId(), !member)
+		return err
+	}
+	return nil
 }
 
-func (s *ServerState) GetAdminUserList() []User {
-	var users []User
-	for _, user := range s.Users {
-		if user.IsAdmin() {
-			users = append(users, user)
+var (
+	ErrServerFull         = errors.New("server is full")
+	ErrTooManyConnections = errors.New("too many connections from the address")
+)
+
+// CreateSession logs a user in from an address if the server limits allow it
+// and returns the new session. Admins can log in when the server is full, their
+// sessions still count toward max clients
+func (s *ServerState) CreateSession(user *User, address string, client string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.GetId()]; !ok {
+		return nil, errors.New("user not registered")
+	}
+
+	fromAddress := 0
+	for _, session := range s.sessionIds {
+		if address != "" && session.GetAddress() == address {
+			fromAddress++
 		}
 	}
 
-	return users
+	if s.maxClients > 0 && len(s.sessionIds) >= s.maxClients && !user.IsAdmin() {
+		return nil, ErrServerFull
+	}
+	if s.maxPerAddress > 0 && fromAddress >= s.maxPerAddress {
+		return nil, ErrTooManyConnections
+	}
+
+	session := NewSession(user, s.sessionTTL, address, client)
+	s.sessions[session.GetToken()] = session
+	s.sessionIds[session.GetId()] = session
+	user.SetConnected(true)
+	return session, nil
+}
+
+// RefreshSession gives a session a new token and expiry, the old token stops
+// working
+func (s *ServerState) RefreshSession(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessionIds[session.GetId()]; !ok {
+		return errors.New("session ended")
+	}
+
+	delete(s.sessions, session.GetToken())
+	session.refresh(s.sessionTTL)
+	s.sessions[session.GetToken()] = session
+	return nil
 }
 
-func (s *ServerState) GetUserById(id string) User {
-	return s.Users[id]
+// EndSession logs a session out, the user is no longer connected when it was
+// its last one
+func (s *ServerState) EndSession(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessionIds[session.GetId()]; !ok {
+		return errors.New("session ended")
+	}
+
+	s.endSession(session)
+	return nil
+}
+
+// EndUserSessions logs out every session of a user and returns them
+func (s *ServerState) EndUserSessions(user *User) []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.endUserSessions(user)
+}
+
+// ExpireSessions ends the sessions that expired, or that were idle for longer
+// than idle and are not kept by alive, and returns them. Clients that crashed
+// before opening a stream do not hold their sessions until they expire
+func (s *ServerState) ExpireSessions(idle time.Duration, alive func(session *Session) bool) []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*Session
+	for _, session := range s.sessionIds {
+		if session.IsExpired() || (time.Since(session.GetLastActive()) > idle && !alive(session)) {
+			s.endSession(session)
+			expired = append(expired, session)
+		}
+	}
+	return expired
+}
+
+// endUserSessions ends every session of a user, the caller must hold the lock
+func (s *ServerState) endUserSessions(user *User) []*Session {
+	var ended []*Session
+	for _, session := range s.sessionIds {
+		if session.GetUser() == user {
+			s.endSession(session)
+			ended = append(ended, session)
+		}
+	}
+	return ended
 }
 
-func (s *ServerState) GetUserByUsername(user string) User {
-	for _, u := range s.Users {
-		if u.GetUsername() == user {
-			return u
+// endSession removes a session and disconnects its user when it has no other
+// one, the caller must hold the lock
+func (s *ServerState) endSession(session *Session) {
+	delete(s.sessions, session.GetToken())
+	delete(s.sessionIds, session.GetId())
+
+	user := session.GetUser()
+	for _, other := range s.sessionIds {
+		if other.GetUser() == user {
+			return
 		}
 	}
+	user.SetConnected(false)
+	user.SetAway(false)
+}
+
+// GetSessionByToken returns nil if there is no session with the token or it
+// expired
+func (s *ServerState) GetSessionByToken(token string) *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[token]
+	if !ok || session.IsExpired() {
+		return nil
+	}
+	return session
+}
 
-	return User{}
+// GetSessionById returns nil if the session does not exist
+func (s *ServerState) GetSessionById(id string) *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sessionIds[id]
 }
 
-func (s *ServerState) GetUserByToken(token string) User {
-	for _, u := range s.Users {
-		if u.GetToken() == token {
-			return u
+// GetUserSessions returns the sessions of a user, oldest first
+func (s *ServerState) GetUserSessions(user *User) []*Session {
+	s.mu.RLock()
+	var sessions []*Session
+	for _, session := range s.sessionIds {
+		if session.GetUser() == user {
+			sessions = append(sessions, session)
 		}
 	}
+	s.mu.RUnlock()
 
-	return User{}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].GetCreated().Before(sessions[j].GetCreated())
+	})
+	return sessions
+}
+
+// filterUsers returns the users accepted by keep sorted by username
+func (s *ServerState) filterUsers(keep func(user *User) bool) []*User {
+	s.mu.RLock()
+	var users []*User
+	for _, user := range s.users {
+		if keep(user) {
+			users = append(users, user)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].GetUsername() < users[j].GetUsername()
+	})
+	return users
+}
+
+func (s *ServerState) GetUserList() []*User {
+	return s.filterUsers(func(user *User) bool { return true })
+}
+
+func (s *ServerState) GetConnectedUserList() []*User {
+	return s.filterUsers((*User).IsConnected)
+}
+
+func (s *ServerState) GetBannedUserList() []*User {
+	return s.filterUsers((*User).IsBanned)
+}
+
+func (s *ServerState) GetAdminUserList() []*User {
+	return s.filterUsers((*User).IsAdmin)
+}
+
+// user getters return nil if the user does not exist
+func (s *ServerState) GetUserById(id string) *User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.users[id]
+}
+
+func (s *ServerState) GetUserByUsername(user string) *User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.usernames[user]
+}
+
+func (s *ServerState) GetName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.name
 }
 
 func (s *ServerState) GetServerPassword() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.serverPass
 }
 
 func (s *ServerState) GetMaxClients() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.maxClients
 }
 
+func (s *ServerState) GetMaxPerAddress() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.maxPerAddress
+}
+
+func (s *ServerState) SetMaxPerAddress(max int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxPerAddress = max
+	return nil
+}
+
+func (s *ServerState) SetName(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.name = name
+	return nil
+}
+
 func (s *ServerState) SetServerPassword(password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.serverPass = password
 	return nil
 }
 
 func (s *ServerState) SetMaxClients(max int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.maxClients = max
 	return nil
 }
 
+func (s *ServerState) GetAwayTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.awayTimeout
+}
+
+func (s *ServerState) SetAwayTimeout(timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.awayTimeout = timeout
+	return nil
+}
+
+func (s *ServerState) GetSlowMode() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.slowMode
+}
+
+func (s *ServerState) SetSlowMode(interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slowMode = interval
+	return nil
+}
+
+// GetLockout returns the failed logins before an account is locked and for
+// how long it is locked
+func (s *ServerState) GetLockout() (int, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lockoutAfter, s.lockoutDuration
+}
+
+func (s *ServerState) SetLockout(after int, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lockoutAfter = after
+	s.lockoutDuration = duration
+	return nil
+}
+
+func (s *ServerState) GetSessionTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sessionTTL
+}
+
+func (s *ServerState) SetSessionTTL(ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessionTTL = ttl
+	return nil
+}
+
 func (s *ServerState) GetCaPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.caPath
 }
 
+func (s *ServerState) GetCaKeyPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.caKeyPath
+}
+
 func (s *ServerState) GetCertPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.certPath
 }
 
 func (s *ServerState) GetKeyPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.keyPath
 }
 
 func (s *ServerState) SetCaPath(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.caPath = path
 	return nil
 }
 
+func (s *ServerState) SetCaKeyPath(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.caKeyPath = path
+	return nil
+}
+
 func (s *ServerState) SetCertPath(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.certPath = path
 	return nil
 }
 
 func (s *ServerState) SetKeyPath(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.keyPath = path
 	return nil
 }
 
+// GetCurrentClients returns the number of sessions
 func (s *ServerState) GetCurrentClients() int {
-	return len(s.GetConnectedUserList())
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.sessionIds)
 }
 
 func (s *ServerState) SetPort(port int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.port = port
-    return nil
+	return nil
 }
 
 func (s *ServerState) GetPort() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.port
 }
 
-//server state constructor
-func NewServerState() *ServerState {
+// server state constructor, users are persisted in the given storage
+func NewServerState(storage Storage) *ServerState {
 	s := &ServerState{
-		Users: make(map[string]User),
+		users:      make(map[string]*User),
+		usernames:  make(map[string]*User),
+		rooms:      make(map[string]*Room),
+		sessions:   make(map[string]*Session),
+		sessionIds: make(map[string]*Session),
+		storage:    storage,
 	}
 
 	return s
 }
-
-
-
-
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"sync"
+	"time"
+
+	"github.com/corrreia/chatroom-grpc/utils"
+)
+
+// session struct, safe for concurrent use. A session is one login of a user
+// from one device, the token identifies it in requests and changes when the
+// session is refreshed while the id stays the same
+type Session struct {
+	mu sync.RWMutex
+
+	id    string
+	token string
+	user  *User
+
+	created    time.Time
+	lastActive time.Time
+	expires    time.Time
+
+	address string //ip the session was opened from
+	client  string //client description sent on login
+}
+
+func NewSession(user *User, ttl time.Duration, address string, client string) *Session {
+	now := time.Now()
+	return &Session{
+		id:         utils.GenerateId(),
+		token:      utils.GenerateToken(),
+		user:       user,
+		created:    now,
+		lastActive: now,
+		expires:    now.Add(ttl),
+		address:    address,
+		client:     client,
+	}
+}
+
+func (s *Session) GetId() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.id
+}
+
+func (s *Session) GetToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token
+}
+
+func (s *Session) GetUser() *User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.user
+}
+
+func (s *Session) GetCreated() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.created
+}
+
+func (s *Session) GetLastActive() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastActive
+}
+
+func (s *Session) GetExpires() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.expires
+}
+
+func (s *Session) GetAddress() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.address
+}
+
+func (s *Session) GetClient() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.client
+}
+
+func (s *Session) IsExpired() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !time.Now().Before(s.expires)
+}
+
+// Touch records a request made with the session
+func (s *Session) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastActive = time.Now()
+}
+
+// refresh gives the session a new token valid for ttl, the server state
+// indexes the token so it is only called through it
+func (s *Session) refresh(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = utils.GenerateToken()
+	s.expires = time.Now().Add(ttl)
+}
@@ -0,0 +1,35 @@
+package types
+
+// UserRecord is the persisted form of a user, sessions are not persisted
+type UserRecord struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"password"` // bcrypt hash
+	Admin    bool   `json:"admin"`
+	Banned   bool   `json:"banned"`
+}
+
+// Storage persists the registered users, the rooms and the chat history of
+// the server state
+type Storage interface {
+	LoadUsers() ([]UserRecord, error)
+	SaveUser(user UserRecord) error
+	DeleteUser(id string) error
+
+	LoadRooms() ([]RoomRecord, error)
+	SaveRoom(room RoomRecord) error
+
+	AppendMessage(message Message) error
+	// LoadMessages returns the last limit messages newer than sinceId accepted
+	// by keep, oldest first. A limit of 0 returns all of them and a nil keep
+	// accepts every message
+	LoadMessages(sinceId uint64, limit int, keep func(message Message) bool) ([]Message, error)
+	LastMessageId() (uint64, error)
+
+	// direct messages waiting for a user to connect
+	QueueMessage(userId string, message Message) error
+	CountQueuedMessages(userId string) (int, error)
+	TakeQueuedMessages(userId string) ([]Message, error)
+
+	Close() error
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"sync"
+	"time"
+
 	"github.com/corrreia/chatroom-grpc/utils"
 )
 
@@ -8,32 +11,40 @@ import (
 type Userer interface {
 	GetId() string
 	GetUsername() string
-	GetToken() string
 	GetPassword() string
 	IsAdmin() bool
 	IsBanned() bool
 	IsConnected() bool
+	IsAway() bool
+	GetLastActive() time.Time
 
 	SetUsername(name string) error
-	SetToken(token string) error
 	SetPassword(password string) error
 	SetAdmin(admin bool) error
 	SetBanned(banned bool) error
 	SetConnected(connected bool) error
+	SetAway(away bool) error
+
+	Touch() bool
 
-	RegenerateToken() error
-	CheckPassword() bool
+	CheckPassword(password string) bool
 }
 
+//user struct, safe for concurrent use. The username of a registered user is
+//indexed by the server state, change it through it. Logins are sessions kept
+//by the server state, a user is connected while it has one
 type User struct {
+	mu sync.RWMutex
+
 	id string
 	username string
 	password string
-	token    string
 
 	admin bool
 	banned bool	
 	connected bool
+	away bool //idle for a while, only meaningful while connected
+	lastActive time.Time
 }
 
 func NewUser(id string, username string, password string) *User {
@@ -41,52 +52,105 @@ func NewUser(id string, username string, password string) *User {
 		id: id,
 		username: username,
 		password: password,
-		token: utils.GenerateToken(),
 		admin: false,
 		banned: false,
 		connected: false,
 	}
 }
 
-func (u User) GetId() string {
+//NewUserFromRecord restores a stored user, it is not connected
+func NewUserFromRecord(record UserRecord) *User {
+	return &User{
+		id: record.Id,
+		username: record.Username,
+		password: record.Password,
+		admin: record.Admin,
+		banned: record.Banned,
+	}
+}
+
+//Record returns the persisted form of the user
+func (u *User) Record() UserRecord {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return UserRecord{
+		Id:       u.id,
+		Username: u.username,
+		Password: u.password,
+		Admin:    u.admin,
+		Banned:   u.banned,
+	}
+}
+
+func (u *User) GetId() string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return u.id
 }
 
 func (u *User) GetUsername() string {
-	return u.username
-}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
 
-func (u *User) GetToken() string {
-	return u.token
+	return u.username
 }
 
 func (u *User) GetPassword() string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return u.password
 }
 
 func (u *User) IsAdmin() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return u.admin
 }
 
 func (u *User) IsBanned() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return u.banned
 }
 
 func (u *User) IsConnected() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return u.connected
 }
 
-func (u *User) SetUsername(name string) error {
-	u.username = name
-	return nil
+func (u *User) IsAway() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.away
+}
+
+func (u *User) GetLastActive() time.Time {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.lastActive
 }
 
-func (u *User) SetToken(token string) error {
-	u.token = token
+func (u *User) SetUsername(name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.username = name
 	return nil
 }
 
 func (u *User) SetPassword(password string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	hash, err := utils.GenerateHash(password)
 	if err != nil {
 		return err
@@ -96,25 +160,54 @@ func (u *User) SetPassword(password string) error {
 }
 
 func (u *User) SetAdmin(admin bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	u.admin = admin
 	return nil
 }
 
 func (u *User) SetBanned(banned bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	u.banned = banned
 	return nil
 }
 
+//SetConnected changes if the user is connected, the server state sets it
+//when sessions are created and ended
 func (u *User) SetConnected(connected bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	u.connected = connected
 	return nil
 }
 
-func (u *User) RegenerateToken() error {
-	u.token = utils.GenerateToken()
+func (u *User) SetAway(away bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.away = away
 	return nil
 }
 
+//Touch records an action of the user, it is no longer away. It reports if
+//the user was away
+func (u *User) Touch() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	wasAway := u.away
+	u.away = false
+	u.lastActive = time.Now()
+	return wasAway
+}
+
 func (u *User) CheckPassword(password string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
 	return utils.CheckPassword(password, u.password)
 }
\ No newline at end of file
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxChainLength is the most certificates accepted in a ca file
+const maxChainLength = 8
+
+// CAChain is the content of a ca certificate file: the self signed root
+// clients pin, and the intermediate cas signed by it, in the order of the
+// file.
+type CAChain struct {
+	Root  *x509.Certificate
+	Certs []*x509.Certificate // all of them, root included
+}
+
+// ParseCAChain reads a PEM file holding a root ca and the intermediate cas
+// below it, in any order. Every intermediate must be a ca that chains up to
+// the root, and the file must hold nothing else, so what is trusted is what
+// the root fingerprint vouches for.
+func ParseCAChain(data []byte) (*CAChain, error) {
+	chain := &CAChain{}
+	for {
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			break
+		}
+
+		block, rest := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" || !bytes.HasPrefix(data, []byte("-----BEGIN")) {
+			return nil, errors.New("only certificates are allowed in a ca file")
+		}
+		data = rest
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !cert.IsCA {
+			return nil, fmt.Errorf("%v is not a ca certificate", cert.Subject.CommonName)
+		}
+		if selfSigned(cert) {
+			if chain.Root != nil {
+				return nil, errors.New("more than one root certificate found")
+			}
+			chain.Root = cert
+		}
+		chain.Certs = append(chain.Certs, cert)
+	}
+
+	switch {
+	case len(chain.Certs) == 0:
+		return nil, errors.New("no certificate found")
+	case len(chain.Certs) > maxChainLength:
+		return nil, fmt.Errorf("more than %d certificates found", maxChainLength)
+	case chain.Root == nil:
+		return nil, errors.New("no root certificate found")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(chain.Root)
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain.Certs {
+		if cert != chain.Root {
+			intermediates.AddCert(cert)
+		}
+	}
+	for _, cert := range chain.Certs {
+		if cert == chain.Root {
+			continue
+		}
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v is not signed by the root: %v", cert.Subject.CommonName, err)
+		}
+	}
+
+	return chain, nil
+}
+
+// selfSigned reports if a certificate is its own issuer.
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// Fingerprint returns the fingerprint of the root, the one clients pin.
+func (c *CAChain) Fingerprint() string {
+	return Fingerprint(c.Root.Raw)
+}
+
+// PEM encodes the certificates of the chain in their order, without anything
+// else the file held.
+func (c *CAChain) PEM() []byte {
+	var b bytes.Buffer
+	for _, cert := range c.Certs {
+		pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return b.Bytes()
+}
+
+// RootPool returns a pool trusting only the root. The intermediates are not
+// trust anchors, servers send the ones their certificate needs.
+func (c *CAChain) RootPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Root)
+	return pool
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a DER certificate, as colon
+// separated hex bytes like openssl prints it.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
+
+// CertFingerprint returns the fingerprint of the root of a PEM ca file, see
+// ParseCAChain.
+func CertFingerprint(data []byte) (string, error) {
+	chain, err := ParseCAChain(data)
+	if err != nil {
+		return "", err
+	}
+	return chain.Fingerprint(), nil
+}
+
+// SameFingerprint compares two fingerprints ignoring case and separators, so
+// users can paste them in any common format.
+func SameFingerprint(a string, b string) bool {
+	normalize := func(s string) string {
+		s = strings.ToUpper(s)
+		return strings.NewReplacer(":", "", " ", "", "-", "").Replace(s)
+	}
+	return a != "" && normalize(a) == normalize(b)
+}
@@ -5,7 +5,7 @@ import (
 )
 
 func CheckPassword(password string, hash string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(password), []byte(hash)) == nil
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
 
 func GenerateHash(password string) (string, error) {
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"os"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
 )
 
 func GenerateToken() string {
@@ -13,4 +17,32 @@ func GenerateToken() string {
         os.Exit(1)
     }
 	return fmt.Sprintf("%x", token)
-}
\ No newline at end of file
+}
+
+// GenerateId returns a random identifier used for user ids
+func GenerateId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return fmt.Sprintf("%x", id)
+}
+
+// TokenFromContext returns the bearer token sent in the authorization metadata
+// of an incoming request
+func TokenFromContext(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get("authorization") {
+		if strings.HasPrefix(value, "Bearer ") {
+			token := strings.TrimPrefix(value, "Bearer ")
+			return token, token != ""
+		}
+	}
+
+	return "", false
+}